@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// goCommands are the go commands that can be run with named sources.
+var goCommands = map[string]bool{
+	"build": true,
+	"test":  true,
+	"run":   true,
+	"vet":   true,
+}
+
+// An overlay replaces named sources with their translations for the go
+// command. See "go help build" for the format of the -overlay file.
+type overlay struct {
+	dir     string
+	Replace map[string]string
+}
+
+// add replaces filename with out, and hides its generated file so that its
+// declarations are not duplicated.
+func (o *overlay) add(filename string, out []byte) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	translated := filepath.Join(o.dir, fmt.Sprintf("%d_%s", len(o.Replace), filepath.Base(abs)))
+	if err := ioutil.WriteFile(translated, out, 0644); err != nil {
+		return err
+	}
+
+	o.Replace[abs] = translated
+	if _, err := os.Stat(outputFilename(abs)); err == nil {
+		o.Replace[outputFilename(abs)] = ""
+	}
+
+	return nil
+}
+
+// translatedFiles returns the named sources that the overlay replaces.
+func (o *overlay) translatedFiles() (filenames []string) {
+	for filename, translated := range o.Replace {
+		if translated != "" {
+			filenames = append(filenames, filename)
+		}
+	}
+
+	return
+}
+
+// write writes the overlay file and returns its name.
+func (o *overlay) write() (string, error) {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+
+	filename := filepath.Join(o.dir, "overlay.json")
+
+	return filename, ioutil.WriteFile(filename, data, 0644)
+}
+
+// buildFlagsWithValue are the flags of the go commands that take a value
+// as the next argument, unless it is given after "=".
+var buildFlagsWithValue = map[string]bool{
+	"-C": true, "-p": true, "-o": true, "-asmflags": true, "-buildmode": true,
+	"-buildvcs": true, "-compiler": true, "-gccgoflags": true, "-gcflags": true,
+	"-installsuffix": true, "-ldflags": true, "-mod": true, "-modfile": true,
+	"-overlay": true, "-pgo": true, "-pkgdir": true, "-tags": true,
+	"-toolexec": true, "-covermode": true, "-coverpkg": true, "-exec": true,
+	"-bench": true, "-benchtime": true, "-blockprofile": true,
+	"-blockprofilerate": true, "-count": true, "-coverprofile": true,
+	"-cpu": true, "-cpuprofile": true, "-fuzz": true, "-fuzztime": true,
+	"-list": true, "-memprofile": true, "-memprofilerate": true,
+	"-mutexprofile": true, "-mutexprofilefraction": true, "-outputdir": true,
+	"-parallel": true, "-run": true, "-shuffle": true, "-skip": true,
+	"-timeout": true, "-trace": true, "-vettool": true,
+}
+
+// packageArgs returns the packages and files that the go command is run
+// with in args. The arguments of go run after its package or files, and of
+// go test after -args, are passed to the program and left out.
+func packageArgs(command string, args []string) (packages []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-args" || arg == "--args":
+			return
+		case strings.HasPrefix(arg, "-"):
+			name := strings.Replace(arg, "--", "-", 1)
+			if !strings.Contains(name, "=") && buildFlagsWithValue[name] {
+				i++
+			}
+			continue
+		}
+
+		if command == "run" && len(packages) > 0 &&
+			!(strings.HasSuffix(arg, ".go") && strings.HasSuffix(packages[0], ".go")) {
+			return
+		}
+		packages = append(packages, arg)
+	}
+
+	return
+}
+
+// isLocalPath reports whether the package argument arg is a path in the
+// file system, rather than an import path. See "go help packages".
+func isLocalPath(arg string) bool {
+	return filepath.IsAbs(arg) || arg == "." || arg == ".." ||
+		strings.HasPrefix(arg, "./") || strings.HasPrefix(arg, "../") ||
+		strings.HasSuffix(arg, ".go")
+}
+
+// dependencies returns the directories of the packages that packages
+// depend on, including themselves, as the go command finds them with the
+// translations in overlayFile. The standard library and the modules other
+// than the main module are left out, since they have no named sources.
+func dependencies(overlayFile string, packages []string) ([]string, error) {
+	const format = `{{if not .Standard}}{{if or (not .Module) .Module.Main}}{{.Dir}}{{end}}{{end}}`
+	args := append([]string{"list", "-e", "-deps", "-overlay=" + overlayFile, "-f", format}, packages...)
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %s", err)
+	}
+
+	return strings.Fields(string(out)), nil
+}
+
+// runGo implements the build, test, run and vet commands. The named sources
+// of the packages in args, or of the current directory if there are none,
+// and of the packages of the main module that they depend on are translated
+// into a temporary directory. Then the go command is run with an overlay
+// that replaces each named source with its translation.
+//
+// Directories and "dir/..." patterns are translated as by the translate
+// command, so that packages made only of named sources are found. Import
+// paths, and the dependencies, are resolved by go list, which sees the
+// translations already made, and so the imports of named sources too.
+func runGo(command string, args []string) int {
+	dir, err := ioutil.TempDir("", "go-named-params")
+	if err != nil {
+		report(err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	o := &overlay{dir: dir, Replace: make(map[string]string)}
+	var s summary
+	translated := make(map[string]bool) // directories
+	packages := packageArgs(command, args)
+	for _, arg := range packages {
+		if !isLocalPath(arg) && !strings.HasSuffix(arg, "/...") && arg != "..." {
+			// an import path, resolved by go list below
+			continue
+		}
+
+		// The translations are not written next to their sources, so their
+		// line directives must use absolute filenames.
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			report(err)
+			return 1
+		}
+
+		if root, ok := isRecursivePattern(path); ok {
+			translateTree(root, &s, o.add)
+		} else if info, err := os.Stat(path); err == nil && info.IsDir() {
+			translateDir(path, &s, o.add)
+			translated[path] = true
+		} else if strings.HasSuffix(path, ".go") && isNamedSource(path) {
+			src, err := ioutil.ReadFile(path)
+			if err == nil {
+				src, err = translate(path, src)
+			}
+			if err == nil {
+				err = o.add(path, src)
+			}
+			if err != nil {
+				report(err)
+				s.failed++
+			}
+		} else if err != nil {
+			report(err)
+			s.failed++
+		}
+	}
+
+	if len(packages) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			report(err)
+			return 1
+		}
+		translateDir(cwd, &s, o.add)
+		translated[cwd] = true
+	}
+
+	for _, filename := range o.translatedFiles() {
+		translated[filepath.Dir(filename)] = true
+	}
+
+	for s.failed == 0 {
+		overlayFile, err := o.write()
+		if err != nil {
+			report(err)
+			return 1
+		}
+
+		dirs, err := dependencies(overlayFile, packages)
+		if err != nil {
+			report(err)
+			return 1
+		}
+
+		done := true
+		for _, dir := range dirs {
+			if !translated[dir] {
+				translateDir(dir, &s, o.add)
+				translated[dir] = true
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	if s.failed > 0 {
+		return 1
+	}
+
+	overlayFile, err := o.write()
+	if err != nil {
+		report(err)
+		return 1
+	}
+
+	cmd := exec.Command("go", append([]string{command, "-overlay=" + overlayFile}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+
+		report(err)
+		return 1
+	}
+
+	return 0
+}
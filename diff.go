@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+func writeTempFile(data []byte) (string, error) {
+	file, err := ioutil.TempFile("", "go-named-params")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// diff returns a unified diff of b1 and b2, the old and new contents of
+// filename, using the diff command.
+func diff(filename string, b1, b2 []byte) ([]byte, error) {
+	f1, err := writeTempFile(b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile(b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	data, err := exec.Command("diff", "-u", "-L", filename+".orig", "-L", filename, f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't match.
+		// Ignore that failure as long as we get output.
+		err = nil
+	}
+
+	return data, err
+}
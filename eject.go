@@ -0,0 +1,288 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goParser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"./parser"
+)
+
+// sourceIdent returns the identifier that starts at offset in src.
+func sourceIdent(src []byte, offset int) string {
+	end := offset
+	for end < len(src) {
+		r, size := utf8.DecodeRune(src[end:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		end += size
+	}
+
+	return string(src[offset:end])
+}
+
+// mangledNames adds the functions, methods and interface methods of file
+// that were declared with named parameters to names, mapping each mangled
+// name to the name written in src.
+func mangledNames(fset *token.FileSet, file *ast.File, src []byte, names map[string]string) {
+	add := func(name *ast.Ident, typ *ast.FuncType) {
+		base := sourceIdent(src, fset.Position(name.Pos()).Offset)
+		if base != name.Name {
+			names[name.Name] = base
+		}
+	}
+
+	parser.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			add(n.Name, n.Type)
+		case *ast.InterfaceType:
+			interfaceMethods(n, add)
+		}
+
+		return true
+	})
+}
+
+// ejectRenames returns the mangled names that can be renamed back to their
+// base name in files. That is only possible where the mangled name is the
+// only overload of its base name, and the base name is not used by any other
+// identifier.
+func ejectRenames(files []*ast.File, mangled map[string]string) map[string]string {
+	used := make(map[string]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+
+			return true
+		})
+	}
+
+	overloads := make(map[string]int)
+	for _, base := range mangled {
+		overloads[base]++
+	}
+
+	renames := make(map[string]string)
+	for name, base := range mangled {
+		if overloads[base] == 1 && !used[base] {
+			renames[name] = base
+		}
+	}
+
+	return renames
+}
+
+// importName returns the name that spec is referred to by.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	path := strings.Trim(spec.Path.Value, `"`)
+
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// missingImports returns the imports of the other files that file refers to
+// without importing them itself. The ejected calls of a function declared in
+// another file write its default values and parameter types as they are
+// written there, so they may refer to the imports of that file.
+func missingImports(files []*ast.File, file *ast.File) (missing []*ast.ImportSpec) {
+	declared := make(map[string]bool)
+	imports := make(map[string]*ast.ImportSpec)
+	for _, f := range files {
+		for name := range f.Scope.Objects {
+			declared[name] = true
+		}
+		for _, spec := range f.Imports {
+			imports[importName(spec)] = spec
+		}
+	}
+	for _, spec := range file.Imports {
+		declared[importName(spec)] = true
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil && !declared[ident.Name] && imports[ident.Name] != nil {
+				missing = append(missing, imports[ident.Name])
+				declared[ident.Name] = true
+			}
+		}
+
+		return true
+	})
+
+	return
+}
+
+// importEdits returns the edits that add specs to the first import
+// declaration of file, or a new one after its package clause.
+func importEdits(fset *token.FileSet, file *ast.File, specs []*ast.ImportSpec) []edit {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	text := ""
+	for _, spec := range specs {
+		if spec.Name != nil {
+			text += spec.Name.Name + " "
+		}
+		text += spec.Path.Value + "\n"
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
+			if decl.Lparen.IsValid() {
+				return []edit{{offset(decl.Rparen), offset(decl.Rparen), text}}
+			}
+			return []edit{
+				{offset(decl.Specs[0].Pos()), offset(decl.Specs[0].Pos()), "(\n" + text},
+				{offset(decl.Specs[0].End()), offset(decl.Specs[0].End()), "\n)"},
+			}
+		}
+	}
+
+	return []edit{{offset(file.Name.End()), offset(file.Name.End()), "\n\nimport (\n" + text + ")"}}
+}
+
+// eject translates the package in dir into plain Go in outDir. Named
+// sources are translated in place of their source file, their generated
+// files are left out and all other Go files are copied.
+func eject(dir, outDir string) error {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	named := make(map[string]bool)
+	for _, filename := range filenames {
+		named[filename] = isNamedSource(filename)
+	}
+
+	namedFset := token.NewFileSet()
+	var namedFiles []*ast.File
+	var namedNames []string
+	for _, filename := range filenames {
+		if named[filename] {
+			if file, err := parser.ParseFile(namedFset, filename, nil, 0); err == nil {
+				namedFiles = append(namedFiles, file)
+				namedNames = append(namedNames, filename)
+			}
+		}
+	}
+	packageFiles, err := checkPackage(namedFset, dir, namedFiles, namedNames...)
+	if err != nil {
+		return err
+	}
+	index := parser.NewIndex(packageFiles)
+
+	var outNames []string
+	outputs := make(map[string][]byte)
+	mangled := make(map[string]string)
+	for _, filename := range filenames {
+		if source, err := sourceFilename(filename); err == nil && named[source] {
+			continue
+		}
+
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		if named[filename] {
+			file, err := parser.ParseFile(namedFset, filename, src, goParser.ParseComments)
+			if err != nil {
+				return err
+			}
+			if err := index.Check(namedFset, file); err != nil {
+				return err
+			}
+
+			mangledNames(namedFset, file, src, mangled)
+			src = []byte((&parser.Config{Index: index, Eject: true}).RenderFile(file, namedFset))
+			src = applyEdits(src, headerEdits(src))
+		}
+
+		outNames = append(outNames, filename)
+		outputs[filename] = src
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, len(outNames))
+	for i, filename := range outNames {
+		files[i], err = goParser.ParseFile(fset, filename, outputs[filename], goParser.ParseComments)
+		if err != nil {
+			return err
+		}
+	}
+
+	renames := ejectRenames(files, mangled)
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	for i, filename := range outNames {
+		var edits []edit
+		edits = append(edits, importEdits(fset, files[i], missingImports(files, files[i]))...)
+		ast.Inspect(files[i], func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok {
+				if base, ok := renames[ident.Name]; ok {
+					start := fset.Position(ident.Pos()).Offset
+					edits = append(edits, edit{start, start + len(ident.Name), base})
+				}
+			}
+
+			return true
+		})
+
+		out, err := format.Source(applyEdits(outputs[filename], edits))
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(outDir, filepath.Base(filename)), out, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runEject implements the eject command.
+func runEject(args []string) int {
+	flags := flag.NewFlagSet("eject", flag.ExitOnError)
+	outDir := flags.String("o", "", "directory to write the plain Go package to")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: go-named-params eject -o outdir dir\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *outDir == "" {
+		flags.Usage()
+		return 2
+	}
+
+	if err := eject(flags.Arg(0), *outDir); err != nil {
+		report(err)
+		return 1
+	}
+
+	return 0
+}
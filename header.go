@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"./parser"
+)
+
+// generatedMarker marks a translation as generated, so that tools and
+// reviewers leave it alone. See "go help generate" for the format.
+const generatedMarker = "// Code generated by go-named-params. DO NOT EDIT."
+
+// isIgnoreConstraint reports whether comment is the build constraint that
+// keeps a named source out of builds.
+func isIgnoreConstraint(comment string) bool {
+	fields := strings.Fields(strings.TrimPrefix(comment, "//"))
+
+	return len(fields) == 2 && (fields[0] == "+build" || fields[0] == "go:build") && fields[1] == "ignore"
+}
+
+// keptDirectivePrefix replaces the "//" of the go:generate directives of
+// this tool in a translation, so that go generate does not run them on the
+// translation, and of the ignore constraints that undo cannot tell from
+// generatedMarker.
+const keptDirectivePrefix = "//named:"
+
+// oldIgnoreConstraint is the ignore constraint that undo writes in place of
+// generatedMarker.
+const oldIgnoreConstraint = "// +build ignore"
+
+// isOwnGenerate reports whether comment is a go:generate directive that
+// runs this tool.
+func isOwnGenerate(comment string) bool {
+	return strings.HasPrefix(comment, "//go:generate ") && strings.Contains(comment, "go-named-params")
+}
+
+// headerEdits returns the edits that remove the ignore constraints and the
+// go:generate directives of this tool from the comments before the package
+// clause of src. Each comment is replaced by an empty line, so every other
+// line keeps its number.
+func headerEdits(src []byte) (edits []edit) {
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok != token.COMMENT {
+			return
+		}
+
+		if isIgnoreConstraint(lit) || isOwnGenerate(lit) {
+			start := fset.Position(pos).Offset
+			edits = append(edits, edit{start, start + len(lit), ""})
+		}
+	}
+}
+
+// render returns the translation of file, which was parsed from filename,
+// with the header of a generated file. The calls of file are matched with
+// the declarations in index. If the only ignore constraint of the header is
+// oldIgnoreConstraint, it is replaced by generatedMarker. Otherwise the
+// marker is added as a new first line, and the ignore constraints are kept
+// after keptDirectivePrefix, as are the go:generate directives of this
+// tool, so that undo can restore them as they were. If line directives are
+// enabled, the marker is followed by one, which maps the rest of the file
+// to the named source.
+func render(filename string, file *ast.File, fset *token.FileSet, index *parser.Index) []byte {
+	cfg := &parser.Config{LineDirectives: *lineDirectives, Index: index}
+	out := []byte(cfg.RenderFile(file, fset))
+
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Base(filename)
+	}
+	header := func(line int) string {
+		if !*lineDirectives {
+			return generatedMarker
+		}
+		return generatedMarker + "\n//line " + filename + ":" + strconv.Itoa(line)
+	}
+
+	edits := headerEdits(out)
+	var constraints []int
+	for i, e := range edits {
+		if isIgnoreConstraint(string(out[e.start:e.end])) {
+			constraints = append(constraints, i)
+		}
+	}
+
+	marked := false
+	for i, e := range edits {
+		switch comment := string(out[e.start:e.end]); {
+		case len(constraints) == 1 && constraints[0] == i && comment == oldIgnoreConstraint:
+			// the marker takes the place of the line of the constraint
+			edits[i].text = header(bytes.Count(out[:e.start], []byte("\n")) + 2)
+			marked = true
+		default:
+			edits[i].text = keptDirectivePrefix + strings.TrimPrefix(comment, "//")
+		}
+	}
+	if !marked {
+		edits = append(edits, edit{0, 0, header(1) + "\n"})
+	}
+
+	return applyEdits(out, edits)
+}
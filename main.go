@@ -1,37 +1,220 @@
+// Command go-named-params translates Go source files that use named
+// parameters into plain Go.
+//
+// Usage:
+//
+//	go-named-params [-check] [-d] file.go ...
+//	go-named-params [-check] [-d] dir ...
+//	go-named-params [-check] [-d] ./...
+//	go-named-params undo [-w] file_gen.go ...
+//	go-named-params eject -o outdir dir
+//	go-named-params build|test|run|vet [go flags] [packages]
+//
+// Each file is written next to its source with a "_gen" suffix, so that
+// "hello.go" becomes "hello_gen.go" and "hello_test.go" becomes
+// "hello_gen_test.go". It is intended to be run through go:generate:
+//
+//	//go:generate $GOPATH/bin/go-named-params $GOFILE
+//
+// Directories are searched for files that use named parameters; files
+// that are already plain Go are left untouched. A path ending in "/..."
+// also searches all of the directories below it, in the same way as the go
+// command. A summary of the files that were translated, skipped and failed
+// is printed when directories are given.
+//
+// Unless -line=false is given, the translation has line directives wherever
+// its positions differ from the named source, so that compiler errors,
+// stack traces, coverage and debuggers refer to the named source.
+//
+// With -check or -d no files are written. Each translation is compared with
+// its existing output file instead; stale files are reported, and with -d
+// their differences are printed as a unified diff. The exit status is
+// non-zero if any file is out of date, which makes it suitable for CI.
+//
+// The undo command reads files produced by a translation and prints them
+// with the named parameters restored. Declarations and calls are
+// recognised by their mangled names, such as "sayHello_name_alreadyGreeted".
+// With -w the result is written back to the named source instead, so
+// "hello_gen.go" is restored into "hello.go".
+//
+// The eject command writes the package in dir to outdir as plain Go, with no
+// trace of named parameters. Wherever a function or method is the only
+// overload of its name it gets back its plain name, so
+// "sayHello_name_alreadyGreeted" becomes "sayHello". Mangled names are kept
+// where there are real overloads, or where the plain name is already used.
+//
+// The build, test, run and vet commands run the go command of the same name
+// without writing any generated files. The named sources of the packages
+// given, or of the current directory, are translated into a temporary
+// directory and passed to the go command with -overlay.
 package main
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
 	"go/ast"
+	goParser "go/parser"
+	"go/scanner"
 	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"./parser"
-	"fmt"
 )
 
-func main() {
-	// src is the input for which we want to print the AST.
-	src := `
-	package main
+var (
+	check          = flag.Bool("check", false, "report generated files that are out of date instead of writing them")
+	showDiff       = flag.Bool("d", false, "display diffs of generated files that are out of date instead of writing them")
+	lineDirectives = flag.Bool("line", true, "add line directives that refer back to the named source")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go-named-params [flags] [file.go | dir | dir/...] ...\n")
+	fmt.Fprintf(os.Stderr, "       go-named-params undo [-w] file_gen.go ...\n")
+	fmt.Fprintf(os.Stderr, "       go-named-params eject -o outdir dir\n")
+	fmt.Fprintf(os.Stderr, "       go-named-params build|test|run|vet [go flags] [packages]\n")
+	flag.PrintDefaults()
+}
+
+// report prints err to standard error. Parse errors are printed one per
+// line in the file:line:col form.
+func report(err error) {
+	scanner.PrintError(os.Stderr, err)
+}
 
-	func anon(a int, b int) {
+// outputFilename returns the name of the file that the translation of
+// filename is written to.
+func outputFilename(filename string) string {
+	if strings.HasSuffix(filename, "_test.go") {
+		return strings.TrimSuffix(filename, "_test.go") + "_gen_test.go"
 	}
 
-	func named(a: int, b: int) {
+	return strings.TrimSuffix(filename, ".go") + "_gen.go"
+}
+
+// translate parses the named source src and returns the plain Go
+// translation.
+func translate(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		return nil, err
 	}
 
-func main() {
-	named(a: 3 + 2, b: 5)
+	files, err := checkPackage(fset, filepath.Dir(filename), []*ast.File{file}, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	index := parser.NewIndex(files)
+	if err := index.Check(fset, file); err != nil {
+		return nil, err
+	}
+
+	return render(filename, file, fset, index), nil
 }
-`
 
-	// Create the AST by parsing src.
-	fset := token.NewFileSet() // positions are relative to fset
-	f, err := parser.ParseFile(fset, "", src, 0)
+// writeOutput writes the translation of filename to its output file. The
+// output file gets the same permissions as filename.
+//
+// With -check or -d nothing is written. Instead the output file is compared
+// with out and an error is returned if it is out of date.
+func writeOutput(filename string, out []byte) error {
+	outName := outputFilename(filename)
+	if *check || *showDiff {
+		current, err := ioutil.ReadFile(outName)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if bytes.Equal(current, out) {
+			return nil
+		}
+
+		if *showDiff {
+			data, err := diff(outName, current, out)
+			if err != nil {
+				return fmt.Errorf("computing diff: %s", err)
+			}
+			os.Stdout.Write(data)
+		}
+
+		return fmt.Errorf("%s: out of date", outName)
+	}
+
+	info, err := os.Stat(filename)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	// Print the AST.
-	ast.Print(fset, f)
+	return ioutil.WriteFile(outName, out, info.Mode().Perm())
+}
+
+// translateFile translates filename and writes the result to its output
+// file.
+func translateFile(filename string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	out, err := translate(filename, src)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(filename, out)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
 
-	fmt.Printf("%s\n", parser.RenderFile(f, fset))
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch command := flag.Arg(0); {
+	case command == "undo":
+		os.Exit(runUndo(flag.Args()[1:]))
+	case command == "eject":
+		os.Exit(runEject(flag.Args()[1:]))
+	case goCommands[command]:
+		os.Exit(runGo(command, flag.Args()[1:]))
+	}
+
+	var s summary
+	showSummary := false
+	for _, arg := range flag.Args() {
+		if root, ok := isRecursivePattern(arg); ok {
+			translateTree(root, &s, writeOutput)
+			showSummary = true
+			continue
+		}
+
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			translateDir(arg, &s, writeOutput)
+			showSummary = true
+			continue
+		}
+
+		if err := translateFile(arg); err != nil {
+			report(err)
+			s.failed++
+		} else {
+			s.translated++
+		}
+	}
+
+	if showSummary {
+		fmt.Println(s)
+	}
+
+	if s.failed > 0 {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	goParser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"./parser"
+)
+
+// summary counts the outcome of each file considered by a translation.
+type summary struct {
+	translated, skipped, failed int
+}
+
+func (s summary) String() string {
+	return fmt.Sprintf("%d translated, %d skipped, %d failed",
+		s.translated, s.skipped, s.failed)
+}
+
+// isRecursivePattern reports whether pattern is of the form "dir/...", and
+// returns the directory it starts from.
+func isRecursivePattern(pattern string) (string, bool) {
+	if pattern == "..." {
+		return ".", true
+	}
+
+	if strings.HasSuffix(pattern, "/...") {
+		return strings.TrimSuffix(pattern, "/..."), true
+	}
+
+	return "", false
+}
+
+// isNamedSource reports whether filename needs to be translated. Any file
+// that the standard parser accepts is already plain Go.
+func isNamedSource(filename string) bool {
+	_, err := goParser.ParseFile(token.NewFileSet(), filename, nil, 0)
+
+	return err != nil
+}
+
+// An emitFunc receives the translation of the named source filename.
+type emitFunc func(filename string, out []byte) error
+
+// translateTree translates each directory below and including root. Like
+// the go command, directories beginning with "." or "_" and testdata
+// directories are ignored.
+func translateTree(root string, s *summary, emit emitFunc) {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") ||
+			strings.HasPrefix(name, "_") || name == "testdata") {
+			return filepath.SkipDir
+		}
+
+		translateDir(path, s, emit)
+
+		return nil
+	})
+	if err != nil {
+		report(err)
+		s.failed++
+	}
+}
+
+// translateDir translates every named source in dir.
+func translateDir(dir string, s *summary, emit emitFunc) {
+	var filenames []string
+	filter := func(info os.FileInfo) bool {
+		filename := filepath.Join(dir, info.Name())
+		if !isNamedSource(filename) {
+			s.skipped++
+			return false
+		}
+
+		filenames = append(filenames, filename)
+		return true
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, filter, goParser.ParseComments)
+	if pkgs == nil {
+		report(err)
+		s.failed++
+		return
+	}
+
+	var named []*ast.File
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			named = append(named, file)
+		}
+	}
+
+	files, err := checkPackage(fset, dir, named, filenames...)
+	if err != nil {
+		report(err)
+		s.failed += len(filenames)
+		return
+	}
+
+	index := parser.NewIndex(files)
+	for _, filename := range filenames {
+		if err := translateParsedFile(fset, pkgs, index, filename, emit); err != nil {
+			report(err)
+			s.failed++
+		} else {
+			s.translated++
+		}
+	}
+}
+
+// parsePackageFiles parses the Go files in dir, except for the named
+// sources in skip and the output files of all named sources, and ignores
+// the files that cannot be parsed. The files are parsed to find the
+// declarations that calls in the named sources refer to. Output files are
+// left out since their mangled names are declared by their sources already.
+func parsePackageFiles(fset *token.FileSet, dir string, skip ...string) (files []*ast.File) {
+	skipped := make(map[string]bool)
+	for _, filename := range skip {
+		skipped[filename] = true
+	}
+
+	filenames, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	exists := make(map[string]bool)
+	for _, filename := range filenames {
+		exists[filename] = true
+	}
+
+	for _, filename := range filenames {
+		if skipped[filename] {
+			continue
+		}
+		if source, err := sourceFilename(filename); err == nil && exists[source] && isNamedSource(source) {
+			continue
+		}
+
+		if file, err := parser.ParseFile(fset, filename, nil, 0); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	return
+}
+
+// builtFiles returns the files among files that are built together with
+// the named sources: the files whose build constraints are satisfied, and
+// the other named sources, which are kept out of builds by their constraint
+// but whose translations are built.
+func builtFiles(fset *token.FileSet, files []*ast.File) (built []*ast.File) {
+	for _, file := range files {
+		filename := fset.Position(file.Package).Filename
+		if ok, err := build.Default.MatchFile(filepath.Dir(filename), filepath.Base(filename)); ok && err == nil || isNamedSource(filename) {
+			built = append(built, file)
+		}
+	}
+
+	return
+}
+
+// checkPackage checks that the mangled names of named do not collide with
+// each other, or with the names declared in the other files of their
+// package. It returns the files of the package to index.
+func checkPackage(fset *token.FileSet, dir string, named []*ast.File, skip ...string) ([]*ast.File, error) {
+	others := parsePackageFiles(fset, dir, skip...)
+	if err := parser.CheckCollisions(fset, append(named, builtFiles(fset, others)...)); err != nil {
+		return nil, err
+	}
+
+	return append(named, others...), nil
+}
+
+// translateParsedFile emits the translation of filename, which has been
+// parsed into pkgs by ParseDir.
+func translateParsedFile(fset *token.FileSet, pkgs map[string]*ast.Package, index *parser.Index, filename string, emit emitFunc) error {
+	for _, pkg := range pkgs {
+		if file, ok := pkg.Files[filename]; ok {
+			if err := index.Check(fset, file); err != nil {
+				return err
+			}
+			return emit(filename, render(filename, file, fset, index))
+		}
+	}
+
+	// ParseDir only returns the first error, so parse the file again to find
+	// out why it is missing.
+	_, err := parser.ParseFile(fset, filename, nil, 0)
+
+	return err
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// This file contains the nodes that this package adds to go/ast. They can
+// be traversed with Walk and Inspect from this package, but not with the
+// ones from go/ast.
+
+// A NamedArg node represents an argument passed by name in a call, such as
+// "b: 3" in "named14(a: 2, b: 3)". The value of a punned argument, such as
+// "b:" in "named14(a: 2, b:)", is an identifier with the name and position
+// of the label.
+type NamedArg struct {
+	Label *ast.Ident // parameter label
+	Colon token.Pos  // position of ":"
+	Value ast.Expr   // argument value
+
+	// ast.Expr has an unexported method, so it can only be implemented by
+	// embedding it. The embedded expression is always nil.
+	ast.Expr
+}
+
+// Pos and End implement ast.Node.
+func (x *NamedArg) Pos() token.Pos { return x.Label.Pos() }
+func (x *NamedArg) End() token.Pos {
+	if x.Punned() {
+		return x.Colon + 1
+	}
+	return x.Value.End()
+}
+
+// Punned reports whether the value of x is implied by its label.
+func (x *NamedArg) Punned() bool { return x.Value.Pos() == x.Label.Pos() }
+
+// A DefaultValue node represents the type of a named parameter that has a
+// default value, such as "int = 443" in "func dial(host: string, port: int =
+// 443)". It is the Type of the parameter's ast.Field.
+type DefaultValue struct {
+	Type   ast.Expr  // parameter type
+	Assign token.Pos // position of "="
+	Value  ast.Expr  // default value
+
+	ast.Expr // always nil, see NamedArg
+}
+
+// Pos and End implement ast.Node.
+func (x *DefaultValue) Pos() token.Pos { return x.Type.Pos() }
+func (x *DefaultValue) End() token.Pos { return x.Value.End() }
+
+// A ParamLabel node represents the type of a named parameter, such as
+// ": string" in "func greet(name: string)", or "to dst: string" in "func
+// move(from src: string, to dst: string)", whose label in calls is not its
+// name. It is the Type of the parameter's ast.Field, and the type it labels
+// may be a DefaultValue. A func type has named parameters if the type of one
+// of its parameters is a ParamLabel.
+type ParamLabel struct {
+	Label *ast.Ident // parameter label, which precedes the name; or nil
+	Colon token.Pos  // position of ":"
+	Type  ast.Expr   // parameter type
+
+	ast.Expr // always nil, see NamedArg
+}
+
+// Pos and End implement ast.Node.
+func (x *ParamLabel) Pos() token.Pos {
+	if x.Label != nil {
+		return x.Label.Pos()
+	}
+	return x.Colon
+}
+func (x *ParamLabel) End() token.Pos { return x.Type.End() }
@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// This file contains the checks of calls and default values against the
+// declarations in an Index. Without them a call with a wrong label would be
+// translated anyway, and only fail to compile because its mangled name is
+// undefined.
+
+// Check checks that every default value in file, which belongs to the
+// package of x, is constant or pure, and that every call with named
+// arguments in file matches exactly one declaration in x. Calls of
+// functions that have no declaration with named parameters in the package
+// are not checked, since they may be declared in another package. Calls of
+// func values are checked against their func types, which the declarations
+// of the package must show. The errors are returned as a
+// scanner.ErrorList.
+func (x *Index) Check(fset *token.FileSet, file *ast.File) error {
+	r := x.resolver(file.Imports)
+	var errors scanner.ErrorList
+	Inspect(file, func(node ast.Node) bool {
+		if typ, ok := node.(*ast.FuncType); ok {
+			defaultParams(typ, func(label string, def ast.Expr) {
+				if r.hasSideEffects(def) {
+					errors.Add(fset.Position(def.Pos()), "default value of "+label+" must be constant or pure")
+				}
+			})
+		}
+
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		labels := callLabels(call)
+		if len(labels) == 0 {
+			if r.leavesOutEveryParam(call) {
+				if msg := r.mismatch(call, nil); msg != "" {
+					errors.Add(fset.Position(funcName(call.Fun).Pos()), msg)
+				}
+			}
+			return true
+		}
+
+		extra := extraValues(call)
+		if typ, isValue := r.funcValueType(call.Fun); isValue {
+			if msg := checkValueLabels(call.Fun, typ, labels); msg != "" {
+				errors.Add(fset.Position(call.Args[0].Pos()), msg)
+			} else if len(extra) > 0 && !isVariadic(typ, labels[len(labels)-1]) {
+				errors.Add(fset.Position(extra[0].Pos()), mixedArgs)
+			}
+			return true
+		}
+
+		name := funcName(call.Fun)
+		if name == nil {
+			return true
+		}
+
+		if len(extra) > 0 {
+			if msg := r.checkExtraValues(call, labels); msg != "" {
+				errors.Add(fset.Position(extra[0].Pos()), msg)
+				return true
+			}
+		}
+
+		if msg := r.mismatch(call, labels); msg != "" {
+			errors.Add(fset.Position(name.Pos()), msg)
+		}
+
+		return true
+	})
+
+	errors.Sort()
+
+	return errors.Err()
+}
+
+// funcName returns the identifier that names the function fun, or nil if
+// it is not referred to by name.
+func funcName(fun ast.Expr) *ast.Ident {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	}
+
+	return nil
+}
+
+// mixedArgs is the error of a call that passes positional arguments after
+// its named arguments.
+const mixedArgs = "cannot mix named and positional arguments"
+
+// extraValues returns the arguments of call that follow its last named
+// argument, which can only be values of a variadic parameter.
+func extraValues(call *ast.CallExpr) []ast.Expr {
+	for i := len(call.Args) - 1; i >= 0; i-- {
+		if _, isNamed := call.Args[i].(*NamedArg); isNamed {
+			return call.Args[i+1:]
+		}
+	}
+
+	return nil
+}
+
+// isVariadic reports whether the parameter of typ labelled label is
+// variadic.
+func isVariadic(typ *ast.FuncType, label string) bool {
+	_, variadic := paramType(typ, label).(*ast.Ellipsis)
+
+	return variadic
+}
+
+// checkExtraValues describes why the values that follow the last named
+// argument of call, with labels, cannot be passed to a variadic parameter.
+// It returns "" if they can, or if the labels match no declaration but one
+// of the declarations has a variadic parameter with the last label, since
+// the mismatch of the labels is the error then. The declaration must be
+// known, or the values could be positional arguments of any parameter.
+func (r *resolver) checkExtraValues(call *ast.CallExpr, labels []string) string {
+	_, funcs := r.candidates(call.Fun)
+	if len(funcs) == 0 {
+		return mixedArgs + ", the declaration of " + valueName(call.Fun) + " is not known"
+	}
+
+	last := labels[len(labels)-1]
+	if fn := lookup(funcs, labels); fn != nil {
+		if isVariadic(fn.typ, last) {
+			return ""
+		}
+		return mixedArgs
+	}
+
+	for _, fn := range funcs {
+		if isVariadic(fn.typ, last) {
+			return ""
+		}
+	}
+
+	return mixedArgs
+}
+
+// mismatch describes why call, with labels, matches no declaration, or
+// more than one. It returns "" if the call is fine, or if the function it
+// calls has no declarations with named parameters in the package.
+func (r *resolver) mismatch(call *ast.CallExpr, labels []string) string {
+	base, funcs := r.candidates(call.Fun)
+	if len(funcs) == 0 || lookup(funcs, labels) != nil {
+		return ""
+	}
+
+	var candidates, matches []string
+	for _, fn := range funcs {
+		candidates = append(candidates, formatLabels(fn.labels))
+		if acceptsLabels(fn.typ, labels) {
+			matches = append(matches, formatLabels(fn.labels))
+		}
+	}
+
+	if len(matches) > 1 {
+		return "ambiguous call of " + base + " with labels " + formatLabels(labels) +
+			"; candidates: " + strings.Join(matches, ", ")
+	}
+
+	msg := "no overload of " + base + " with labels " + formatLabels(labels) +
+		"; candidates: " + strings.Join(candidates, ", ")
+	if suggestion := suggestLabels(funcs, labels); suggestion != "" {
+		msg += "; did you mean " + suggestion + "?"
+	}
+
+	return msg
+}
+
+// suggestLabels returns the labels that were probably meant instead of the
+// unknown ones in labels, such as "b instead of c", or "" if there is no
+// close enough declaration in funcs.
+func suggestLabels(funcs []*namedFunc, labels []string) (suggestion string) {
+	best := -1
+	for _, fn := range funcs {
+		var replacements []string
+		total := 0
+		for _, label := range labels {
+			if paramType(fn.typ, label) != nil {
+				continue
+			}
+
+			closest, distance := "", -1
+			for _, param := range fn.labels {
+				d := editDistance(label, param)
+				if !containsLabel(labels, param) && (distance < 0 || d < distance) {
+					closest, distance = param, d
+				}
+			}
+			limit := len(label) / 2
+			if limit < 1 {
+				limit = 1
+			}
+			if distance < 0 || distance > limit {
+				replacements = nil
+				break
+			}
+
+			replacements = append(replacements, closest+" instead of "+label)
+			total += distance
+		}
+
+		if len(replacements) > 0 && (best < 0 || total < best) {
+			suggestion, best = strings.Join(replacements, ", "), total
+		}
+	}
+
+	return
+}
+
+// containsLabel reports whether labels contains label.
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			next := diagonal + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], next
+		}
+	}
+
+	return row[len(b)]
+}
@@ -0,0 +1,242 @@
+package parser
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// This file contains the check of mangled names against the other names of
+// a package. Mangling joins the labels with underscores, so f(a_b: int) and
+// f(a: int, b: int) are both translated to f_a_b, which may also be the name
+// of a plain function. The compiler would report that f_a_b is redeclared,
+// without saying why. The helpers of the translation, such as the function
+// f_a_b_default_b returning a default value, are checked in the same way.
+
+// A declaration is a name declared in a package, a method set or an
+// interface.
+type declaration struct {
+	name  string // as translated
+	kind  string // "func", "method", "type", and so on
+	named string // the declaration with named parameters, such as "f(a, b)"
+	pos   token.Pos
+}
+
+// funcDeclaration returns the declaration of the function name of type typ.
+// The parser mangles the names of the functions and methods declared with
+// named parameters.
+func funcDeclaration(name *ast.Ident, typ *ast.FuncType, kind string) declaration {
+	d := declaration{name: name.Name, kind: kind, pos: name.Pos()}
+	if isLabelled(typ) {
+		labels := paramLabels(typ)
+		base := strings.TrimSuffix(name.Name, "_"+strings.Join(labels, "_"))
+		d.named = base + formatLabels(labels)
+	}
+
+	return d
+}
+
+// receiverName returns the name of the type of the receiver recv, or "" if
+// it is not valid.
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) != 1 {
+		return ""
+	}
+
+	typ := recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// packageDeclarations returns the declarations of files by scope. The
+// package scope is "", and the scope of the methods and fields of a type
+// is its name.
+func packageDeclarations(files []*ast.File) map[string][]declaration {
+	scopes := make(map[string][]declaration)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					scopes[""] = append(scopes[""], funcDeclaration(decl.Name, decl.Type, "func"))
+				} else if recv := receiverName(decl.Recv); recv != "" {
+					scopes[recv] = append(scopes[recv], funcDeclaration(decl.Name, decl.Type, "method"))
+				}
+
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						scopes[""] = append(scopes[""], declaration{name: spec.Name.Name, kind: "type", pos: spec.Name.Pos()})
+						if st, ok := spec.Type.(*ast.StructType); ok {
+							scopes[spec.Name.Name] = append(scopes[spec.Name.Name], fieldDeclarations(st)...)
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							scopes[""] = append(scopes[""], declaration{name: name.Name, kind: decl.Tok.String(), pos: name.Pos()})
+						}
+					}
+				}
+			}
+		}
+
+		packageFuncTypes(file, func(name string, typ *ast.FuncType) {
+			defaultParams(typ, func(label string, def ast.Expr) {
+				scopes[""] = append(scopes[""], declaration{
+					name: defaultValueName(name, label), kind: "func",
+					named: "the default value of " + label + " of " + name, pos: def.Pos(),
+				})
+			})
+			importedTypes(name, typ, func(alias, what string, t ast.Expr) {
+				scopes[""] = append(scopes[""], declaration{
+					name: alias, kind: "type",
+					named: "the type of " + what + " of " + name, pos: t.Pos(),
+				})
+			})
+		})
+	}
+
+	return scopes
+}
+
+// fieldDeclarations returns the declarations of the fields of st.
+func fieldDeclarations(st *ast.StructType) (decls []declaration) {
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			decls = append(decls, declaration{name: name.Name, kind: "field", pos: name.Pos()})
+		}
+		if len(field.Names) == 0 {
+			// the name of an embedded field is the name of its type
+			if name := embeddedName(field.Type); name != nil {
+				decls = append(decls, declaration{name: name.Name, kind: "field", pos: name.Pos()})
+			}
+		}
+	}
+
+	return
+}
+
+// embeddedName returns the name of the embedded field of type typ.
+func embeddedName(typ ast.Expr) *ast.Ident {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.Ident:
+		return t
+	}
+
+	return nil
+}
+
+// interfaceDeclarations returns the methods of each interface type in
+// files, each interface being a scope of its own.
+func interfaceDeclarations(files []*ast.File) (scopes [][]declaration) {
+	for _, file := range files {
+		Inspect(file, func(node ast.Node) bool {
+			if iface, ok := node.(*ast.InterfaceType); ok {
+				var methods []declaration
+				for _, field := range iface.Methods.List {
+					if typ, ok := field.Type.(*ast.FuncType); ok && len(field.Names) == 1 {
+						methods = append(methods, funcDeclaration(field.Names[0], typ, "method"))
+					}
+				}
+				scopes = append(scopes, methods)
+			}
+
+			return true
+		})
+	}
+
+	return
+}
+
+// checkCollisions adds an error to errors for each declaration with named
+// parameters in scope whose mangled name is also declared by another
+// declaration in scope.
+func checkCollisions(fset *token.FileSet, scope []declaration, errors *scanner.ErrorList) {
+	byName := make(map[string][]declaration)
+	for _, d := range scope {
+		byName[d.name] = append(byName[d.name], d)
+	}
+
+	for name, decls := range byName {
+		if len(decls) < 2 {
+			continue
+		}
+
+		sort.Slice(decls, func(i, j int) bool { return decls[i].pos < decls[j].pos })
+		for i, d := range decls {
+			if d.named == "" {
+				continue
+			}
+
+			var other *declaration
+			for j := range decls {
+				if decls[j].named == "" {
+					other = &decls[j]
+					break
+				}
+			}
+
+			var msg string
+			switch {
+			case other != nil:
+				msg = "mangled name " + name + " of " + d.named + " collides with " + other.kind + " " + name
+			case i == 0:
+				continue
+			case decls[0].named == d.named:
+				other = &decls[0]
+				msg = d.named + " redeclared, previously declared"
+			default:
+				other = &decls[0]
+				msg = "mangled name " + name + " of " + d.named + " is also the mangled name of " + other.named
+			}
+
+			errors.Add(fset.Position(d.pos), msg+" at "+fset.Position(other.pos).String())
+		}
+	}
+}
+
+// CheckCollisions checks the mangled names of the declarations with named
+// parameters in files against the other declarations of the package that
+// files make up: the functions, types, variables and constants of the
+// package, the methods and fields of the receiver type of a method, and the
+// other methods of an interface. The errors are returned as a
+// scanner.ErrorList. Translated files must be left out of files, since
+// their declarations are the mangled names.
+func CheckCollisions(fset *token.FileSet, files []*ast.File) error {
+	var errors scanner.ErrorList
+	byPackage := make(map[string][]*ast.File)
+	for _, file := range files {
+		byPackage[file.Name.Name] = append(byPackage[file.Name.Name], file)
+	}
+
+	for _, files := range byPackage {
+		for _, scope := range packageDeclarations(files) {
+			checkCollisions(fset, scope, &errors)
+		}
+		for _, scope := range interfaceDeclarations(files) {
+			checkCollisions(fset, scope, &errors)
+		}
+	}
+
+	errors.Sort()
+
+	return errors.Err()
+}
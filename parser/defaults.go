@@ -0,0 +1,393 @@
+package parser
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+// This file contains the default values of named parameters. A call may
+// leave out the label of a parameter with a default value, and the
+// translation passes the default value in its place:
+//
+//	func dial(host: string, port: int = 443)
+//	dial(host: "example.com")
+//	//dial_host_port("example.com", 443)
+//
+// A default value may refer to the imports of its file, which the file of
+// the call need not have. So the translation of a declaration at package
+// level declares a function for each of its default values, which the calls
+// call instead:
+//
+//	func dial_host_port_default_port() int { return 443 }
+//	//dial_host_port("example.com", dial_host_port_default_port())
+//
+// These functions are written after HelpersMarker.
+//
+// A call through an imported package cannot see the declaration, so it
+// calls the mangled name of its own labels. The translation of an exported
+// function adds an overload for each set of parameters with default values
+// that such a call may leave out, which passes their default values:
+//
+//	func Dial_host(host string) { Dial_host_port(host, Dial_host_port_default_port()) }
+
+// checkDefaultValues reports the default values in params that refer to
+// another parameter. A default value is evaluated by each call that leaves
+// it out, where the parameters do not exist. Whether it is pure depends on
+// the declarations of the package, so Index.Check reports that.
+func (p *parser) checkDefaultValues(params []*ast.Field) {
+	names := make(map[string]bool)
+	for _, field := range params {
+		for _, name := range field.Names {
+			names[name.Name] = true
+		}
+	}
+
+	for _, field := range params {
+		_, def := fieldType(field)
+		if def == nil || len(field.Names) == 0 {
+			continue
+		}
+
+		label := fieldLabel(field, field.Names[0])
+		var visit func(ast.Node) bool
+		visit = func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.SelectorExpr:
+				// the selected name is not a parameter
+				Inspect(n.X, visit)
+				return false
+			case *ast.Ident:
+				if names[n.Name] {
+					p.error(n.Pos(), "default value of "+label+" refers to parameter "+n.Name)
+				}
+			}
+
+			return true
+		}
+		Inspect(def, visit)
+	}
+}
+
+// packageFuncTypes calls fn with each func type with named parameters that
+// file declares at package level: the types of its functions and methods,
+// the func types it names, the func-typed fields and methods of its struct
+// and interface types, and the types of its variables. name is the name of
+// the declaration in the translation, prefixed with the type it belongs to.
+func packageFuncTypes(file *ast.File, fn func(name string, typ *ast.FuncType)) {
+	add := func(name string, typ ast.Expr) {
+		if typ, ok := typ.(*ast.FuncType); ok && isLabelled(typ) {
+			fn(name, typ)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil {
+				name = receiverName(decl.Recv) + "_" + name
+			}
+			add(name, decl.Type)
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name.Name, spec.Type)
+
+					var fields []*ast.Field
+					switch t := spec.Type.(type) {
+					case *ast.StructType:
+						fields = t.Fields.List
+					case *ast.InterfaceType:
+						fields = t.Methods.List
+					}
+					for _, field := range fields {
+						if len(field.Names) > 0 {
+							add(spec.Name.Name+"_"+field.Names[0].Name, field.Type)
+						}
+					}
+
+				case *ast.ValueSpec:
+					if len(spec.Names) > 0 {
+						add(spec.Names[0].Name, spec.Type)
+					}
+				}
+			}
+		}
+	}
+}
+
+// defaultParams calls fn with the label and default value of each
+// parameter of typ that has one.
+func defaultParams(typ *ast.FuncType, fn func(label string, def ast.Expr)) {
+	for _, field := range typ.Params.List {
+		if _, def := fieldType(field); def != nil {
+			for _, name := range field.Names {
+				fn(fieldLabel(field, name), def)
+			}
+		}
+	}
+}
+
+// overloads calls fn with the name and the labels of each overload that
+// the translation of the exported function name of type typ adds for the
+// calls through an imported package that leave out parameters with default
+// values. name is the mangled name of the function, and the overload that
+// leaves out every parameter has its base name. There is no overload for
+// labels that a call in the package would match with another declaration,
+// or whose mangled name is declared in the package.
+func (x *Index) overloads(name string, typ *ast.FuncType, fn func(overload string, labels []string)) {
+	labels := paramLabels(typ)
+	base := strings.TrimSuffix(name, "_"+strings.Join(labels, "_"))
+	if base == name || !ast.IsExported(base) {
+		return
+	}
+
+	var funcs []*namedFunc
+	var self *namedFunc
+	for _, f := range x.funcs[base] {
+		if !f.method {
+			funcs = append(funcs, f)
+			if f.typ == typ {
+				self = f
+			}
+		}
+	}
+	if self == nil {
+		// a method or a func type
+		return
+	}
+
+	var defaults []string
+	defaultParams(typ, func(label string, _ ast.Expr) {
+		defaults = append(defaults, label)
+	})
+	for omitted := 1; omitted < 1<<uint(len(defaults)); omitted++ {
+		left := make(map[string]bool)
+		for i, label := range defaults {
+			left[label] = omitted&(1<<uint(i)) != 0
+		}
+
+		var kept []string
+		for _, label := range labels {
+			if !left[label] {
+				kept = append(kept, label)
+			}
+		}
+		overload := base
+		if len(kept) > 0 {
+			overload += "_" + strings.Join(kept, "_")
+		}
+
+		if lookup(funcs, kept) == self && x.decls[overload] == nil {
+			fn(overload, kept)
+		}
+	}
+}
+
+// writeOverload writes the overload of the function name of type typ that
+// has the parameters labelled labels, and passes the default values of the
+// others. The line directive maps the call to the declaration.
+func (f *outputFile) writeOverload(name, overload string, labels []string, typ *ast.FuncType) {
+	params := new(ast.FieldList)
+	var args []string
+	for _, field := range typ.Params.List {
+		var names []*ast.Ident
+		for _, ident := range field.Names {
+			label := fieldLabel(field, ident)
+			switch {
+			case !containsLabel(labels, label):
+				args = append(args, defaultValueName(name, label)+"()")
+			case isVariadic(typ, label):
+				names = append(names, ident)
+				args = append(args, ident.Name+"...")
+			default:
+				names = append(names, ident)
+				args = append(args, ident.Name)
+			}
+		}
+		if len(names) > 0 {
+			params.List = append(params.List, &ast.Field{Names: names, Type: field.Type})
+		}
+	}
+
+	body := name + "(" + strings.Join(args, ", ") + ")"
+	if typ.Results.NumFields() > 0 {
+		body = "return " + body
+	}
+
+	signature := f.nodeString(&ast.FuncType{Params: params, Results: typ.Results})
+	f.writeRaw("\nfunc " + overload + strings.TrimPrefix(signature, "func") + " {\n\t")
+	f.writeLineDirectiveAt(f.position(typ.Pos()))
+	f.writeToken(body)
+	f.writeRaw("\n}\n")
+}
+
+// defaultValueName returns the name of the function that returns the
+// default value of the parameter labelled label of the declaration name.
+func defaultValueName(name, label string) string {
+	return name + "_default_" + label
+}
+
+// paramDefault returns the default value of the parameter of typ labelled
+// label, or nil if it has none or typ is nil.
+func paramDefault(typ *ast.FuncType, label string) ast.Expr {
+	if typ == nil {
+		return nil
+	}
+
+	for _, field := range typ.Params.List {
+		for _, name := range field.Names {
+			if fieldLabel(field, name) == label {
+				_, def := fieldType(field)
+				return def
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasDefaults reports whether a parameter of typ has a default value.
+func hasDefaults(typ *ast.FuncType) bool {
+	for _, field := range typ.Params.List {
+		if _, def := fieldType(field); def != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// acceptsLabels reports whether a function of type typ can be called with
+// labels, in any order. Every label must be the label of a parameter, and
+// every parameter that has no label must have a default value.
+func acceptsLabels(typ *ast.FuncType, labels []string) bool {
+	given := make(map[string]bool)
+	for _, label := range labels {
+		given[label] = true
+	}
+
+	params := paramLabels(typ)
+	for _, param := range params {
+		if !given[param] && paramDefault(typ, param) == nil {
+			return false
+		}
+		delete(given, param)
+	}
+
+	return len(given) == 0 && len(labels) <= len(params)
+}
+
+// plainType returns a copy of typ without the labels and default values of
+// the parameters of the func types in it, which is how typ is written in the
+// translation.
+func plainType(typ ast.Expr) ast.Expr {
+	switch t := typ.(type) {
+	case *ParamLabel:
+		return plainType(t.Type)
+	case *DefaultValue:
+		return plainType(t.Type)
+	case *ast.FuncType:
+		copied := *t
+		copied.Params = plainFields(t.Params)
+		copied.Results = plainFields(t.Results)
+		return &copied
+	case *ast.StarExpr:
+		copied := *t
+		copied.X = plainType(t.X)
+		return &copied
+	case *ast.ParenExpr:
+		copied := *t
+		copied.X = plainType(t.X)
+		return &copied
+	case *ast.Ellipsis:
+		copied := *t
+		copied.Elt = plainType(t.Elt)
+		return &copied
+	case *ast.ArrayType:
+		copied := *t
+		copied.Elt = plainType(t.Elt)
+		return &copied
+	case *ast.MapType:
+		copied := *t
+		copied.Key, copied.Value = plainType(t.Key), plainType(t.Value)
+		return &copied
+	case *ast.ChanType:
+		copied := *t
+		copied.Value = plainType(t.Value)
+		return &copied
+	case *ast.StructType:
+		copied := *t
+		copied.Fields = plainFields(t.Fields)
+		return &copied
+	case *ast.InterfaceType:
+		copied := *t
+		copied.Methods = plainFields(t.Methods)
+		return &copied
+	}
+
+	return typ
+}
+
+// plainFields returns a copy of list with the types of its fields replaced
+// by their plainType.
+func plainFields(list *ast.FieldList) *ast.FieldList {
+	if list == nil {
+		return nil
+	}
+
+	copied := *list
+	copied.List = nil
+	for _, field := range list.List {
+		plain := *field
+		plain.Type = plainType(field.Type)
+		copied.List = append(copied.List, &plain)
+	}
+
+	return &copied
+}
+
+// defaultValue returns the default value of the parameter of typ labelled
+// label, as a call that leaves it out passes it. If typ is declared at
+// package level, that is a call of the function returning the value, unless
+// the file is ejected. Otherwise the value is written without its positions.
+func (f *outputFile) defaultValue(typ *ast.FuncType, label string) string {
+	if x := f.resolver.index; x != nil && !f.eject {
+		if name, ok := x.names[typ]; ok {
+			return defaultValueName(name, label) + "()"
+		}
+	}
+
+	return f.nodeString(paramDefault(typ, label))
+}
+
+// writeDefaultValue writes the function name returning the default value
+// def of type typ. The line directive maps the value to the source.
+func (f *outputFile) writeDefaultValue(name string, typ, def ast.Expr) {
+	f.writeRaw("\nfunc " + name + "() " + f.nodeString(typ) + " {\n\treturn ")
+	f.writeLineDirectiveAt(f.position(def.Pos()))
+	f.writeToken(f.nodeString(def))
+	f.writeRaw("\n}\n")
+}
+
+var (
+	commentEnd        = regexp.MustCompile(`\*(\\*)/`)
+	escapedCommentEnd = regexp.MustCompile(`\*(\\*)\\/`)
+)
+
+// escapeComment returns text with a backslash added before the slash of
+// every "*/", so that text can be written in a comment, such as the default
+// value of a parameter in the translation. The backslashes that are there
+// already get one more, so that UnescapeComment can tell them apart.
+func escapeComment(text string) string {
+	return commentEnd.ReplaceAllString(text, `*${1}\/`)
+}
+
+// UnescapeComment returns the text that a comment of the translation was
+// escaped from by escapeComment.
+func UnescapeComment(text string) string {
+	return escapedCommentEnd.ReplaceAllString(text, "*${1}/")
+}
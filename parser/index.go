@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// A namedFunc is a function, method or interface method that was declared
+// with named parameters.
+type namedFunc struct {
+	name   string   // mangled name
+	labels []string // parameter labels, in declaration order
+	typ    *ast.FuncType
+	pos    token.Pos // position of the name
+	method bool      // a method or interface method
+	recv   string    // receiver type or interface type, if it has a name
+}
+
+// An Index holds the declarations with named parameters of a package, so
+// that a call can be matched with its declaration even if the labels of the
+// call are in a different order. It also holds the other declarations of
+// the package, which show the types of the receivers of method calls. The
+// zero Index is empty.
+type Index struct {
+	funcs   map[string][]*namedFunc  // by base name
+	decls   map[string]*ast.Ident    // package-level names, by name
+	methods map[string]*ast.FuncDecl // by receiver type and name, as in "T.m"
+
+	// the names of the package-level declarations with named parameters,
+	// by func type, which prefix the names of their helpers
+	names map[*ast.FuncType]string
+}
+
+// NewIndex returns the index of the declarations in files, which must
+// belong to the same package. Files that were translated already may be
+// included: their mangled names are recognised as well.
+func NewIndex(files []*ast.File) *Index {
+	x := &Index{
+		funcs:    make(map[string][]*namedFunc),
+		decls:    make(map[string]*ast.Ident),
+		methods:  make(map[string]*ast.FuncDecl),
+		names:    make(map[*ast.FuncType]string),
+	}
+	for _, file := range files {
+		x.addDecls(file)
+		packageFuncTypes(file, func(name string, typ *ast.FuncType) {
+			x.names[typ] = name
+		})
+
+		interfaces := make(map[*ast.InterfaceType]string)
+		Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				recv := ""
+				if n.Recv != nil {
+					recv = receiverName(n.Recv)
+				}
+				x.add(n.Name, n.Type, n.Recv != nil, recv)
+			case *ast.TypeSpec:
+				if iface, ok := n.Type.(*ast.InterfaceType); ok {
+					interfaces[iface] = n.Name.Name
+				}
+			case *ast.InterfaceType:
+				for _, field := range n.Methods.List {
+					if typ, ok := field.Type.(*ast.FuncType); ok && len(field.Names) == 1 {
+						x.add(field.Names[0], typ, true, interfaces[n])
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	return x
+}
+
+// addDecls adds the package-level names and the methods declared in file.
+func (x *Index) addDecls(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				x.decls[decl.Name.Name] = decl.Name
+			} else if recv := receiverName(decl.Recv); recv != "" {
+				x.methods[recv+"."+decl.Name.Name] = decl
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					x.decls[spec.Name.Name] = spec.Name
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						x.decls[name.Name] = name
+					}
+				}
+			}
+		}
+	}
+}
+
+// add adds the function ident of type typ if its name is mangled, that is,
+// if it is a base name followed by the label of every parameter, each
+// preceded by an underscore. A method has the receiver type recv.
+func (x *Index) add(ident *ast.Ident, typ *ast.FuncType, method bool, recv string) {
+	name := ident.Name
+	labels := paramLabels(typ)
+	if len(labels) == 0 || len(labels) != typ.Params.NumFields() {
+		return
+	}
+
+	suffix := "_" + strings.Join(labels, "_")
+	if len(name) <= len(suffix) || !strings.HasSuffix(name, suffix) {
+		return
+	}
+
+	base := strings.TrimSuffix(name, suffix)
+	for _, fn := range x.funcs[base] {
+		if fn.name == name && fn.method == method && fn.recv == recv {
+			// declared again by the translation of a named source,
+			// which has lost the default values
+			if hasDefaults(typ) {
+				fn.typ, fn.pos = typ, ident.Pos()
+			}
+			return
+		}
+	}
+	x.funcs[base] = append(x.funcs[base], &namedFunc{
+		name: name, labels: labels, typ: typ, pos: ident.Pos(), method: method, recv: recv,
+	})
+}
+
+// sortedLabels returns a sorted copy of labels.
+func sortedLabels(labels []string) []string {
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// candidates returns the name of the function that a call of fun calls, as
+// it is written in the call, and the declarations with named parameters
+// that the call may be of. A call of a method is only matched with the
+// methods of the type of its receiver, if the type is known, and a call
+// through an imported package is not matched at all.
+func (r *resolver) candidates(fun ast.Expr) (base string, funcs []*namedFunc) {
+	if r.index == nil {
+		return "", nil
+	}
+
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		for _, fn := range r.index.funcs[fun.Name] {
+			if !fn.method {
+				funcs = append(funcs, fn)
+			}
+		}
+		return fun.Name, funcs
+
+	case *ast.SelectorExpr:
+		if r.isPackage(fun.X) {
+			return "", nil
+		}
+
+		recvs := r.receivers(r.exprType(fun.X))
+		for _, fn := range r.index.funcs[fun.Sel.Name] {
+			if fn.method && (recvs == nil || containsLabel(recvs, fn.recv)) {
+				funcs = append(funcs, fn)
+			}
+		}
+		return fun.Sel.Name, funcs
+	}
+
+	return "", nil
+}
+
+// callee returns the declaration that call calls, or nil if it is not
+// known.
+func (r *resolver) callee(call *ast.CallExpr) *namedFunc {
+	_, funcs := r.candidates(call.Fun)
+
+	return lookup(funcs, callLabels(call))
+}
+
+// leavesOutEveryParam reports whether call has no arguments and calls a
+// function that has declarations with named parameters in the package but
+// none without them, so that the call can only be of a declaration whose
+// parameters all have default values. A call of a method is only matched if
+// the type of its receiver is known.
+func (r *resolver) leavesOutEveryParam(call *ast.CallExpr) bool {
+	if r.index == nil || len(call.Args) > 0 {
+		return false
+	}
+
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if r.object(fun) != nil {
+			return false
+		}
+
+	case *ast.SelectorExpr:
+		if r.isPackage(fun.X) {
+			return false
+		}
+		recvs := r.receivers(r.exprType(fun.X))
+		if recvs == nil {
+			return false
+		}
+		for _, recv := range recvs {
+			if r.index.methods[recv+"."+fun.Sel.Name] != nil {
+				return false
+			}
+		}
+
+	default:
+		return false
+	}
+
+	_, funcs := r.candidates(call.Fun)
+
+	return len(funcs) > 0
+}
+
+// lookup returns the declaration in funcs that a call with labels calls.
+// The labels may be in any order, and may leave out parameters with default
+// values. A declaration with the same labels in the same order is
+// preferred, then one with the same labels in any order. It returns nil if
+// there is none, or if more than one declaration matches equally well.
+func lookup(funcs []*namedFunc, labels []string) *namedFunc {
+	want := formatLabels(sortedLabels(labels))
+	matches := []func(fn *namedFunc) bool{
+		func(fn *namedFunc) bool { return formatLabels(fn.labels) == formatLabels(labels) },
+		func(fn *namedFunc) bool { return formatLabels(sortedLabels(fn.labels)) == want },
+		func(fn *namedFunc) bool { return acceptsLabels(fn.typ, labels) },
+	}
+	for _, matches := range matches {
+		var match *namedFunc
+		n := 0
+		for _, fn := range funcs {
+			if matches(fn) {
+				match = fn
+				n++
+			}
+		}
+
+		switch n {
+		case 0:
+			continue
+		case 1:
+			return match
+		}
+
+		return nil
+	}
+
+	return nil
+}
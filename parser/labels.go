@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// callLabels returns the labels of the named arguments of call, in order.
+func callLabels(call *ast.CallExpr) (labels []string) {
+	for _, arg := range call.Args {
+		if arg, ok := arg.(*NamedArg); ok {
+			labels = append(labels, arg.Label.Name)
+		}
+	}
+
+	return
+}
+
+// paramLabels returns the labels of the parameters of typ, in order.
+func paramLabels(typ *ast.FuncType) (labels []string) {
+	for _, field := range typ.Params.List {
+		for _, name := range field.Names {
+			labels = append(labels, fieldLabel(field, name))
+		}
+	}
+
+	return
+}
+
+// fieldLabel returns the label of the parameter name declared by field,
+// which is the name itself unless the ParamLabel of the field has a label.
+func fieldLabel(field *ast.Field, name *ast.Ident) string {
+	if label, ok := field.Type.(*ParamLabel); ok && label.Label != nil {
+		return label.Label.Name
+	}
+
+	return name.Name
+}
+
+// fieldType returns the type of the parameters declared by field, without
+// their label, and their default value, which is nil if they have none.
+func fieldType(field *ast.Field) (typ, def ast.Expr) {
+	typ = field.Type
+	if label, ok := typ.(*ParamLabel); ok {
+		typ = label.Type
+	}
+	if value, ok := typ.(*DefaultValue); ok {
+		return value.Type, value.Value
+	}
+
+	return typ, nil
+}
+
+// formatLabels formats labels as they are written in diagnostics.
+func formatLabels(labels []string) string {
+	return "(" + strings.Join(labels, ", ") + ")"
+}
+
+// isLabelled reports whether typ was declared with named parameters.
+func isLabelled(typ *ast.FuncType) bool {
+	if typ.Params == nil {
+		return false
+	}
+
+	for _, field := range typ.Params.List {
+		if _, ok := field.Type.(*ParamLabel); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// funcValueType reports whether fun is a value of a func type, such as a
+// variable, a parameter, a struct field or the result of a call, rather
+// than a declared function or method, and returns its func type if the
+// declarations show it. Calls of a value cannot be mangled, because the
+// name of the value does not depend on its parameters.
+func (r *resolver) funcValueType(fun ast.Expr) (typ *ast.FuncType, isValue bool) {
+	switch f := fun.(type) {
+	case *ast.ParenExpr:
+		return r.funcValueType(f.X)
+	case *ast.Ident:
+		if obj := r.object(f); obj == nil || obj.Kind != ast.Var {
+			return nil, false
+		}
+	case *ast.SelectorExpr:
+		if r.field(f) == nil {
+			return nil, false
+		}
+	}
+
+	return r.funcType(r.exprType(fun)), true
+}
+
+// valueName returns the name of the func value fun as it is written in
+// diagnostics.
+func valueName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.ParenExpr:
+		return valueName(f.X)
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return valueName(f.X) + "." + f.Sel.Name
+	}
+
+	return "func value"
+}
+
+// checkValueLabels returns the error of a call of the func value fun of
+// type typ with labels, or "" if the labels are fine. The labels must be
+// the parameter names of a func type declared with named parameters, in any
+// order, and may leave out those with default values.
+func checkValueLabels(fun ast.Expr, typ *ast.FuncType, labels []string) string {
+	name := valueName(fun)
+	switch {
+	case typ == nil:
+		return "cannot check the labels of " + name + ", its func type is not known"
+	case !isLabelled(typ):
+		return name + " has no parameter labels"
+	case !acceptsLabels(typ, labels):
+		return "wrong labels " + formatLabels(labels) + " for " + name + ", want " + formatLabels(paramLabels(typ))
+	}
+
+	return ""
+}
+
+// checkCalls checks the calls in decls against the declarations in the
+// file: the labels of the calls of func values against their func types,
+// and the values that follow the last named argument against the variadic
+// parameters. The other calls are checked by Index.Check, which sees the
+// whole package.
+func (p *parser) checkCalls(decls []ast.Decl) {
+	x := NewIndex([]*ast.File{{Name: ast.NewIdent(""), Decls: decls}})
+	r := x.resolver(p.imports)
+	for _, decl := range decls {
+		Inspect(decl, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			labels := callLabels(call)
+			if len(labels) == 0 {
+				return true
+			}
+
+			extra := extraValues(call)
+			typ, isValue := r.funcValueType(call.Fun)
+			if !isValue {
+				if _, funcs := r.candidates(call.Fun); len(funcs) > 0 && len(extra) > 0 {
+					if msg := r.checkExtraValues(call, labels); msg != "" {
+						p.error(extra[0].Pos(), msg)
+					}
+				}
+				return true
+			}
+			if typ == nil {
+				return true
+			}
+
+			if msg := checkValueLabels(call.Fun, typ, labels); msg != "" {
+				p.error(call.Args[0].Pos(), msg)
+			} else if len(extra) > 0 && !isVariadic(typ, labels[len(labels)-1]) {
+				p.error(extra[0].Pos(), mixedArgs)
+			}
+
+			return true
+		})
+	}
+}
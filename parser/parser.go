@@ -69,6 +69,7 @@ type parser struct {
 	// (maintained by open/close LabelScope)
 	labelScope  *ast.Scope     // label scope for current function
 	targetStack [][]*ast.Ident // stack of unresolved labels
+
 }
 
 func (p *parser) init(fset *token.FileSet, filename string, src []byte, mode goParser.Mode) {
@@ -763,13 +764,25 @@ func (p *parser) parsePointerType() *ast.StarExpr {
 	return &ast.StarExpr{Star: star, X: base}
 }
 
-// If the result is an identifier, it is not resolved.
+// If the result is an identifier, it is not resolved. The type of a named
+// parameter is a ParamLabel, whose type is resolved.
 func (p *parser) tryVarType(isParam bool) (ast.Expr, bool) {
-	isNamed := false
 	if isParam && p.tok == token.COLON {
-		isNamed = true
+		colon := p.pos
 		p.next()
+		typ := p.tryParamType(isParam, true)
+		if typ == nil {
+			return nil, true
+		}
+		p.resolve(typ)
+		return &ParamLabel{Colon: colon, Type: typ}, true
 	}
+	return p.tryParamType(isParam, false), false
+}
+
+// tryParamType parses the type of a parameter, with the default value of a
+// named parameter.
+func (p *parser) tryParamType(isParam, isNamed bool) ast.Expr {
 	if isParam && p.tok == token.ELLIPSIS {
 		pos := p.pos
 		p.next()
@@ -780,9 +793,23 @@ func (p *parser) tryVarType(isParam bool) (ast.Expr, bool) {
 			p.error(pos, "'...' parameter is missing type")
 			typ = &ast.BadExpr{From: pos, To: p.pos}
 		}
-		return &ast.Ellipsis{Ellipsis: pos, Elt: typ}, isNamed
+		if isNamed && p.tok == token.ASSIGN {
+			p.error(p.pos, "variadic parameter cannot have a default value")
+			p.next()
+			p.parseRhs()
+		}
+		return &ast.Ellipsis{Ellipsis: pos, Elt: typ}
+	}
+	typ := p.tryIdentOrType()
+	if isNamed && typ != nil && p.tok == token.ASSIGN {
+		// the type of a named parameter is never a parameter name, so it
+		// can be resolved before the default value is parsed
+		p.resolve(typ)
+		assign := p.pos
+		p.next()
+		return &DefaultValue{Type: typ, Assign: assign, Value: p.parseRhs()}
 	}
-	return p.tryIdentOrType(), isNamed
+	return typ
 }
 
 // If the result is an identifier, it is not resolved.
@@ -833,6 +860,30 @@ func (p *parser) parseVarList(isParam bool) (list []ast.Expr, typ ast.Expr, isNa
 	return
 }
 
+// parseParamLabel parses the type of a parameter whose label is not its
+// name, as in "from src: string". The label has been parsed into idents and
+// the name into typ, since they look like the name and type of an unnamed
+// parameter. Other parameters are returned as they are.
+func (p *parser) parseParamLabel(idents []*ast.Ident, typ ast.Expr, isNamed, isParam bool) ([]*ast.Ident, ast.Expr, bool) {
+	name, isIdent := typ.(*ast.Ident)
+	if !isParam || !isIdent || p.tok != token.COLON {
+		return idents, typ, isNamed
+	}
+
+	if len(idents) > 1 {
+		p.error(idents[1].Pos(), "a parameter with a label must have a single name")
+	}
+	typ, isNamed = p.parseVarType(isParam)
+	label, ok := typ.(*ParamLabel)
+	if !ok {
+		// the type is missing
+		label = &ParamLabel{Type: typ}
+	}
+	label.Label = idents[0]
+
+	return []*ast.Ident{name}, label, isNamed
+}
+
 func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params []*ast.Field, isNamed bool) {
 	if p.trace {
 		defer un(trace(p, "ParameterList"))
@@ -845,6 +896,7 @@ func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params [
 	if typ != nil {
 		// IdentifierList Type
 		idents := p.makeIdentList(list)
+		idents, typ, isNamed = p.parseParamLabel(idents, typ, isNamed, ellipsisOk)
 		field := &ast.Field{Names: idents, Type: typ}
 		params = append(params, field)
 		// Go spec: The scope of an identifier denoting a function
@@ -857,7 +909,9 @@ func (p *parser) parseParameterList(scope *ast.Scope, ellipsisOk bool) (params [
 		p.next()
 		for p.tok != token.RPAREN && p.tok != token.EOF {
 			idents := p.parseIdentList()
-			typ, isNamed = p.parseVarType(ellipsisOk)
+			typ, named := p.parseVarType(ellipsisOk)
+			idents, typ, named = p.parseParamLabel(idents, typ, named, ellipsisOk)
+			isNamed = isNamed || named
 			field := &ast.Field{Names: idents, Type: typ}
 			params = append(params, field)
 			// Go spec: The scope of an identifier denoting a function
@@ -894,6 +948,14 @@ func (p *parser) parseParameters(scope *ast.Scope, ellipsisOk bool) (*ast.FieldL
 	}
 	rparen := p.expect(token.RPAREN)
 
+	for i, field := range params {
+		typ, _ := fieldType(field)
+		if ellipsis, isEllipsis := typ.(*ast.Ellipsis); isEllipsis && (i < len(params)-1 || len(field.Names) > 1) {
+			p.error(ellipsis.Pos(), "can only use ... with final parameter in list")
+		}
+	}
+	p.checkDefaultValues(params)
+
 	return &ast.FieldList{Opening: lparen, List: params, Closing: rparen}, isNamed
 }
 
@@ -939,6 +1001,17 @@ func (p *parser) parseFuncType() (*ast.FuncType, *ast.Scope) {
 	return &ast.FuncType{Func: pos, Params: params, Results: results}, scope
 }
 
+// mangle appends the label of each parameter to the name of a function or
+// method declared with named parameters, so that it can be told apart from
+// other declarations with the same name.
+func (p *parser) mangle(ident *ast.Ident, params *ast.FieldList) {
+	for _, param := range params.List {
+		for _, name := range param.Names {
+			ident.Name += "_" + fieldLabel(param, name)
+		}
+	}
+}
+
 func (p *parser) parseMethodSpec(scope *ast.Scope) *ast.Field {
 	if p.trace {
 		defer un(trace(p, "MethodSpec"))
@@ -952,7 +1025,10 @@ func (p *parser) parseMethodSpec(scope *ast.Scope) *ast.Field {
 		// method
 		idents = []*ast.Ident{ident}
 		scope := ast.NewScope(nil) // method scope
-		params, results, _ := p.parseSignature(scope)
+		params, results, isNamed := p.parseSignature(scope)
+		if isNamed {
+			p.mangle(ident, params)
+		}
 		typ = &ast.FuncType{Func: token.NoPos, Params: params, Results: results}
 	} else {
 		// embedded interface
@@ -1260,6 +1336,37 @@ func (p *parser) parseIndexOrSlice(x ast.Expr) ast.Expr {
 	return &ast.IndexExpr{X: x, Lbrack: lbrack, Index: index[0], Rbrack: rbrack}
 }
 
+// parseArg parses a call argument, which may be passed by name. This is the
+// only place where a colon separates a label from an expression; in all
+// other places it is left to the caller, as in the go/parser.
+func (p *parser) parseArg() ast.Expr {
+	if p.trace {
+		defer un(trace(p, "Arg"))
+	}
+
+	// A label is not resolved, so the argument is parsed like a lhs and
+	// only resolved once we know that it isn't followed by a colon.
+	old := p.inRhs
+	p.inRhs = true
+	x := p.checkExprOrType(p.parseExpr(true)) // builtins may expect a type: make(some type, ...)
+	p.inRhs = old
+
+	if label, isIdent := x.(*ast.Ident); isIdent && p.tok == token.COLON {
+		colon := p.pos
+		p.next()
+		if p.tok == token.COMMA || p.tok == token.RPAREN || p.tok == token.ELLIPSIS {
+			// punned: the value is the identifier with the name of the label
+			value := &ast.Ident{NamePos: label.NamePos, Name: label.Name}
+			p.resolve(value)
+			return &NamedArg{Label: label, Colon: colon, Value: value}
+		}
+		return &NamedArg{Label: label, Colon: colon, Value: p.parseRhsOrType()}
+	}
+	p.resolve(x)
+
+	return x
+}
+
 func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	if p.trace {
 		defer un(trace(p, "CallOrConversion"))
@@ -1270,7 +1377,7 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	var list []ast.Expr
 	var ellipsis token.Pos
 	for p.tok != token.RPAREN && p.tok != token.EOF && !ellipsis.IsValid() {
-		list = append(list, p.parseRhsOrType()) // builtins may expect a type: make(some type, ...)
+		list = append(list, p.parseArg())
 		if p.tok == token.ELLIPSIS {
 			ellipsis = p.pos
 			p.next()
@@ -1282,10 +1389,39 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	}
 	p.exprLev--
 	rparen := p.expectClosing(token.RPAREN, "argument list")
+	p.checkNamedArgs(list)
 
 	return &ast.CallExpr{Fun: fun, Lparen: lparen, Args: list, Ellipsis: ellipsis, Rparen: rparen}
 }
 
+// checkNamedArgs reports a call that mixes named and positional arguments.
+// Only a call with every argument named is translated, any other call is
+// left as it is. The exception are the values of a variadic parameter,
+// which follow its label: join(sep: ",", parts: "a", "b").
+func (p *parser) checkNamedArgs(args []ast.Expr) {
+	var last, variadic *NamedArg
+	for _, arg := range args {
+		named, isNamed := arg.(*NamedArg)
+		switch {
+		case isNamed && variadic != nil:
+			p.error(variadic.Label.Pos(), "variadic argument "+variadic.Label.Name+" must be the last named argument")
+			return
+		case isNamed:
+			last = named
+		case last != nil:
+			variadic = last
+		}
+	}
+
+	if last == nil {
+		return
+	}
+
+	if _, isNamed := args[0].(*NamedArg); !isNamed {
+		p.error(args[0].Pos(), "cannot mix named and positional arguments")
+	}
+}
+
 func (p *parser) parseValue(keyOk bool) ast.Expr {
 	if p.trace {
 		defer un(trace(p, "Element"))
@@ -1599,9 +1735,6 @@ func (p *parser) parseUnaryExpr(lhs bool) ast.Expr {
 
 func (p *parser) tokPrec() (token.Token, int) {
 	tok := p.tok
-	if tok == token.COLON {
-		return tok, token.LowestPrec + 1
-	}
 	if p.inRhs && tok == token.ASSIGN {
 		tok = token.EQL
 	}
@@ -2389,9 +2522,7 @@ func (p *parser) parseFuncDecl() *ast.FuncDecl {
 	params, results, isNamed := p.parseSignature(scope)
 
 	if isNamed {
-		for _, param := range params.List {
-			ident.Name += "_" + param.Names[0].Name
-		}
+		p.mangle(ident, params)
 	}
 
 	var body *ast.BlockStmt
@@ -2515,6 +2646,8 @@ func (p *parser) parseFile() *ast.File {
 		}
 	}
 
+	p.checkCalls(decls)
+
 	return &ast.File{
 		Doc:        doc,
 		Package:    pos,
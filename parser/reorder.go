@@ -0,0 +1,321 @@
+package parser
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// This file contains the translation of calls that pass their named
+// arguments in a different order than the declaration of the function.
+
+// namedArgGroups returns the arguments of call by label. The group of a
+// variadic parameter also holds the values that follow its named argument.
+func namedArgGroups(call *ast.CallExpr) map[string][]ast.Expr {
+	groups := make(map[string][]ast.Expr)
+	label := ""
+	for _, arg := range call.Args {
+		if arg, ok := arg.(*NamedArg); ok {
+			label = arg.Label.Name
+		}
+		groups[label] = append(groups[label], arg)
+	}
+
+	return groups
+}
+
+// pureBuiltins are the builtin functions that only compute a value from
+// their arguments.
+var pureBuiltins = map[string]bool{
+	"cap": true, "complex": true, "imag": true, "len": true, "max": true, "min": true, "real": true,
+}
+
+// basicTypes are the predeclared type names.
+var basicTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// isPureCall reports whether call only computes a value from its arguments:
+// it is a conversion to a type that the declarations show, or a call of a
+// pure builtin function. A call through an imported package may call any
+// function, so it is not pure.
+func (r *resolver) isPureCall(call *ast.CallExpr) bool {
+	if r.isType(call.Fun) {
+		return true
+	}
+
+	ident, ok := call.Fun.(*ast.Ident)
+
+	return ok && r.object(ident) == nil && (basicTypes[ident.Name] || pureBuiltins[ident.Name])
+}
+
+// hasSideEffects reports whether evaluating x may have an effect that the
+// evaluation of another expression can observe, that is, if it calls a
+// function or receives from a channel. Conversions and pure builtins look
+// like calls, but only their arguments are counted.
+func (r *resolver) hasSideEffects(x ast.Node) (effects bool) {
+	Inspect(x, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CallExpr:
+			effects = !r.isPureCall(n)
+		case *ast.UnaryExpr:
+			effects = effects || n.Op == token.ARROW
+		case *ast.FuncLit:
+			// the body is not evaluated
+			return false
+		}
+
+		return !effects
+	})
+
+	return
+}
+
+// reordersSideEffects reports whether passing the arguments of call in the
+// order of labels changes the order in which their side effects happen.
+func (r *resolver) reordersSideEffects(call *ast.CallExpr, labels []string) bool {
+	groups := namedArgGroups(call)
+	effects := func(labels []string) (withEffects []string) {
+		for _, label := range labels {
+			for _, arg := range groups[label] {
+				if r.hasSideEffects(arg) {
+					withEffects = append(withEffects, label)
+					break
+				}
+			}
+		}
+
+		return
+	}
+
+	return formatLabels(effects(callLabels(call))) != formatLabels(effects(labels))
+}
+
+// declaredLabels returns the labels of the parameters of the function that
+// call calls, in the order of its declaration, and the type it is declared
+// with. If the declaration is not known, the labels are returned in the
+// order of the call and the type is nil.
+func (f *outputFile) declaredLabels(call *ast.CallExpr) ([]string, *ast.FuncType) {
+	labels := callLabels(call)
+	if len(labels) == 0 {
+		if f.resolver.leavesOutEveryParam(call) {
+			if fn := f.resolver.callee(call); fn != nil {
+				return fn.labels, fn.typ
+			}
+		}
+		return nil, nil
+	}
+
+	if typ, isValue := f.resolver.funcValueType(call.Fun); isValue {
+		if typ == nil || !acceptsLabels(typ, labels) {
+			return labels, nil
+		}
+		return paramLabels(typ), typ
+	}
+
+	if fn := f.resolver.callee(call); fn != nil {
+		return fn.labels, fn.typ
+	}
+
+	return labels, nil
+}
+
+// mangledSuffix returns the suffix of the mangled name that a call of fun
+// with labels calls. Func values are not mangled.
+func (f *outputFile) mangledSuffix(call *ast.CallExpr, labels []string) string {
+	if _, isValue := f.resolver.funcValueType(call.Fun); isValue || len(labels) == 0 {
+		return ""
+	}
+
+	return "_" + strings.Join(labels, "_")
+}
+
+// nodeString returns node printed by go/printer. The labels and default
+// values of the func types in node are left out, since go/printer does not
+// know them.
+func (f *outputFile) nodeString(node ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, f.fileSet, plainType(node))
+
+	return buf.String()
+}
+
+// paramType returns the type of the parameter of typ labelled label,
+// without its default value.
+func paramType(typ *ast.FuncType, label string) ast.Expr {
+	for _, field := range typ.Params.List {
+		for _, name := range field.Names {
+			if fieldLabel(field, name) == label {
+				typ, _ := fieldType(field)
+				return typ
+			}
+		}
+	}
+
+	return nil
+}
+
+// isQualified reports whether typ refers to a name of an imported package.
+func isQualified(typ ast.Expr) (qualified bool) {
+	Inspect(plainType(typ), func(node ast.Node) bool {
+		if _, ok := node.(*ast.SelectorExpr); ok {
+			qualified = true
+		}
+
+		return !qualified
+	})
+
+	return
+}
+
+// importedTypes calls fn with each parameter and result type of typ, the
+// func type of the package-level declaration name, that refers to an
+// imported package, and with the name of the alias of the type that the
+// translation of the declaration adds. The function literal of a hoisted
+// call in another file writes the alias instead, since that file need not
+// have the imports. what describes the type, such as "parameter d". The
+// element type of a variadic parameter is aliased, and only the func types
+// with more than one parameter, whose calls can be hoisted, have aliases.
+func importedTypes(name string, typ *ast.FuncType, fn func(alias, what string, t ast.Expr)) {
+	if len(paramLabels(typ)) < 2 {
+		return
+	}
+
+	for _, label := range paramLabels(typ) {
+		t := paramType(typ, label)
+		if ellipsis, ok := t.(*ast.Ellipsis); ok {
+			t = ellipsis.Elt
+		}
+		if isQualified(t) {
+			fn(name+"_param_"+label, "parameter "+label, t)
+		}
+	}
+
+	for i := 0; resultType(typ, i) != nil; i++ {
+		if t := resultType(typ, i); isQualified(t) {
+			fn(name+"_result_"+strconv.Itoa(i), "result "+strconv.Itoa(i), t)
+		}
+	}
+}
+
+// writeTypeAlias writes the declaration of alias as the type t. The line
+// directive maps the type to the source.
+func (f *outputFile) writeTypeAlias(alias string, t ast.Expr) {
+	f.writeRaw("\ntype " + alias + " = ")
+	f.writeLineDirectiveAt(f.position(t.Pos()))
+	f.writeToken(f.nodeString(t))
+	f.writeRaw("\n")
+}
+
+// hoistedType returns t, a parameter or result type of typ, as the function
+// literal of a hoisted call writes it: the alias of t if typ is declared at
+// package level, t refers to an imported package and the file is not
+// ejected, or t itself.
+func (f *outputFile) hoistedType(typ *ast.FuncType, t ast.Expr) string {
+	if ellipsis, ok := t.(*ast.Ellipsis); ok {
+		return "..." + f.hoistedType(typ, ellipsis.Elt)
+	}
+
+	if x := f.resolver.index; x != nil && !f.eject {
+		if name, ok := x.names[typ]; ok {
+			found := ""
+			importedTypes(name, typ, func(alias, _ string, u ast.Expr) {
+				if u == t && found == "" {
+					found = alias
+				}
+			})
+			if found != "" {
+				return found
+			}
+		}
+	}
+
+	return f.nodeString(t)
+}
+
+// funcTypeString returns typ as the type of the parameter of a function
+// literal that passes the function of a hoisted call.
+func (f *outputFile) funcTypeString(typ *ast.FuncType) string {
+	var params []string
+	for _, label := range paramLabels(typ) {
+		params = append(params, f.hoistedType(typ, paramType(typ, label)))
+	}
+
+	return "func(" + strings.Join(params, ", ") + ")" + f.resultsString(typ)
+}
+
+// resultsString returns the results of typ as they are written after the
+// parameters of a function literal. Result names are left out, so that
+// they cannot shadow the names used in the call.
+func (f *outputFile) resultsString(typ *ast.FuncType) string {
+	var results []string
+	for i := 0; resultType(typ, i) != nil; i++ {
+		results = append(results, f.hoistedType(typ, resultType(typ, i)))
+	}
+
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		return " " + results[0]
+	}
+
+	return " (" + strings.Join(results, ", ") + ")"
+}
+
+// writeHoisted writes a call whose arguments cannot be reordered, because
+// their side effects would happen in a different order. Instead they are
+// passed in their order to a function literal, which calls the function
+// with them in the order of its declaration:
+//
+//	func(_b int, _a int) int { return named14_a_b(_a, _b) }(f(), g())
+//
+// If evaluating the function has side effects too, as in x().move(...), it
+// is passed to the function literal first, so that it is still evaluated
+// before the arguments. Its parameter is named _0, which cannot collide with
+// the parameters named after the labels.
+func (f *outputFile) writeHoisted(call *ast.CallExpr, labels []string, typ *ast.FuncType) {
+	var params, args []string
+	fun := f.nodeString(call.Fun) + f.mangledSuffix(call, labels)
+	passFun := f.resolver.hasSideEffects(call.Fun)
+	if passFun {
+		params = append(params, "_0 "+f.funcTypeString(typ))
+		fun = "_0"
+	}
+
+	for _, label := range callLabels(call) {
+		params = append(params, "_"+label+" "+f.hoistedType(typ, paramType(typ, label)))
+	}
+
+	for _, label := range labels {
+		arg := "_" + label
+		if def := paramDefault(typ, label); def != nil && len(namedArgGroups(call)[label]) == 0 {
+			arg = f.defaultValue(typ, label)
+		} else if _, isVariadic := paramType(typ, label).(*ast.Ellipsis); isVariadic {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	body := fun + "(" + strings.Join(args, ", ") + ")"
+	if f.resultsString(typ) != "" {
+		body = "return " + body
+	}
+
+	f.writeAt("func("+strings.Join(params, ", ")+")"+f.resultsString(typ)+" { "+body+" }", call.Fun.Pos())
+	f.writeAt("(", call.Lparen)
+	if passFun {
+		f.write(call.Fun)
+		f.writeRaw(f.mangledSuffix(call, labels))
+		if len(call.Args) > 0 {
+			f.write(",")
+		}
+	}
+	f.writeArgs(call, nil, nil)
+}
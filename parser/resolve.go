@@ -0,0 +1,410 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// This file contains the resolution of the types of expressions, as far as
+// the declarations of the package show them. The calls of methods are
+// matched with the declarations of the type of their receiver, so the type
+// has to be known without type-checking the package, which cannot be done
+// before it is translated.
+
+// A resolver finds the declarations that the names of a file refer to,
+// in the file and in the other files of its package.
+type resolver struct {
+	index   *Index
+	imports map[string]bool      // names of the imported packages
+	seen    map[*ast.Object]bool // objects being resolved
+}
+
+// importNames returns the names that imports are referred to by.
+func importNames(imports []*ast.ImportSpec) map[string]bool {
+	names := make(map[string]bool)
+	for _, spec := range imports {
+		name := strings.Trim(spec.Path.Value, `"`)
+		name = name[strings.LastIndex(name, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = true
+	}
+
+	return names
+}
+
+// resolver returns the resolver of the names of a file of the package of x
+// that imports imports. x may be nil, then only the declarations of the
+// file are found.
+func (x *Index) resolver(imports []*ast.ImportSpec) *resolver {
+	return &resolver{index: x, imports: importNames(imports), seen: make(map[*ast.Object]bool)}
+}
+
+// object returns the object that ident refers to, or nil if it is not
+// declared in the package.
+func (r *resolver) object(ident *ast.Ident) *ast.Object {
+	if ident.Obj != nil {
+		return ident.Obj
+	}
+	if r.index != nil {
+		if decl := r.index.decls[ident.Name]; decl != nil {
+			return decl.Obj
+		}
+	}
+
+	return nil
+}
+
+// isPackage reports whether x is the name of an imported package.
+func (r *resolver) isPackage(x ast.Expr) bool {
+	ident, ok := x.(*ast.Ident)
+
+	return ok && r.object(ident) == nil && r.imports[ident.Name]
+}
+
+// typeIdent returns the name of the type typ, without the pointers and type
+// arguments, or nil if typ is not a type name of the package.
+func typeIdent(typ ast.Expr) *ast.Ident {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+// typeSpec returns the declaration of the type named by typ, or nil if it
+// is not declared in the package.
+func (r *resolver) typeSpec(typ ast.Expr) *ast.TypeSpec {
+	ident := typeIdent(typ)
+	if ident == nil {
+		return nil
+	}
+
+	if obj := r.object(ident); obj != nil && obj.Kind == ast.Typ {
+		spec, _ := obj.Decl.(*ast.TypeSpec)
+		return spec
+	}
+
+	return nil
+}
+
+// underlying returns the type literal that typ is declared with, following
+// the type names declared in the package.
+func (r *resolver) underlying(typ ast.Expr) ast.Expr {
+	for i := 0; i < 100; i++ {
+		switch t := typ.(type) {
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.Ident:
+			spec := r.typeSpec(t)
+			if spec == nil {
+				return nil
+			}
+			typ = spec.Type
+		default:
+			return typ
+		}
+	}
+
+	return nil
+}
+
+// funcType returns the func type that typ denotes, or nil if it is not one.
+func (r *resolver) funcType(typ ast.Expr) *ast.FuncType {
+	funcType, _ := r.underlying(typ).(*ast.FuncType)
+
+	return funcType
+}
+
+// resultType returns the type of the i-th result of typ, or nil if it has
+// fewer.
+func resultType(typ *ast.FuncType, i int) ast.Expr {
+	if typ.Results == nil {
+		return nil
+	}
+
+	for _, field := range typ.Results.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		if i < n {
+			return field.Type
+		}
+		i -= n
+	}
+
+	return nil
+}
+
+// isType reports whether x denotes a type, so that a call of x is a
+// conversion.
+func (r *resolver) isType(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.ParenExpr:
+		return r.isType(x.X)
+	case *ast.StarExpr:
+		return r.isType(x.X)
+	case *ast.Ident:
+		obj := r.object(x)
+		return obj != nil && obj.Kind == ast.Typ
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	}
+
+	return false
+}
+
+// exprType returns the type of the expression x, or nil if the declarations
+// do not show it.
+func (r *resolver) exprType(x ast.Expr) ast.Expr {
+	switch x := x.(type) {
+	case *ast.ParenExpr:
+		return r.exprType(x.X)
+
+	case *ast.Ident:
+		obj := r.object(x)
+		if obj == nil || r.seen[obj] {
+			return nil
+		}
+		r.seen[obj] = true
+		defer delete(r.seen, obj)
+
+		return r.objectType(obj)
+
+	case *ast.FuncLit:
+		return x.Type
+
+	case *ast.CompositeLit:
+		return x.Type
+
+	case *ast.UnaryExpr:
+		if typ := r.exprType(x.X); typ != nil && x.Op == token.AND {
+			return &ast.StarExpr{X: typ}
+		}
+
+	case *ast.StarExpr:
+		if ptr, ok := r.underlying(r.exprType(x.X)).(*ast.StarExpr); ok {
+			return ptr.X
+		}
+
+	case *ast.SelectorExpr:
+		if field := r.field(x); field != nil {
+			typ, _ := fieldType(field)
+			return typ
+		}
+
+	case *ast.IndexExpr:
+		switch typ := r.underlying(r.exprType(x.X)).(type) {
+		case *ast.ArrayType:
+			return typ.Elt
+		case *ast.MapType:
+			return typ.Value
+		}
+
+	case *ast.CallExpr:
+		if r.isType(x.Fun) {
+			return x.Fun
+		}
+		if typ := r.calleeType(x); typ != nil {
+			return resultType(typ, 0)
+		}
+	}
+
+	return nil
+}
+
+// objectType returns the type of the variable or function obj.
+func (r *resolver) objectType(obj *ast.Object) ast.Expr {
+	switch decl := obj.Decl.(type) {
+	case *ast.FuncDecl:
+		return decl.Type
+
+	case *ast.Field:
+		typ, _ := fieldType(decl)
+		return typ
+
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return decl.Type
+		}
+		return r.valueType(obj.Name, decl.Names, decl.Values)
+
+	case *ast.AssignStmt:
+		var names []*ast.Ident
+		for _, lhs := range decl.Lhs {
+			name, _ := lhs.(*ast.Ident)
+			names = append(names, name)
+		}
+		return r.valueType(obj.Name, names, decl.Rhs)
+	}
+
+	return nil
+}
+
+// valueType returns the type of the value assigned to name in an
+// assignment of values to names, or nil if it is not known.
+func (r *resolver) valueType(name string, names []*ast.Ident, values []ast.Expr) ast.Expr {
+	for i, ident := range names {
+		if ident == nil || ident.Name != name {
+			continue
+		}
+
+		switch {
+		case len(values) == len(names):
+			return r.exprType(values[i])
+		case len(values) == 1:
+			if call, ok := values[0].(*ast.CallExpr); ok {
+				if typ := r.calleeType(call); typ != nil {
+					return resultType(typ, i)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// calleeType returns the type of the function that call calls, or nil if
+// it is not known.
+func (r *resolver) calleeType(call *ast.CallExpr) *ast.FuncType {
+	if typ, isValue := r.funcValueType(call.Fun); isValue {
+		return typ
+	}
+
+	if fn := r.callee(call); fn != nil {
+		return fn.typ
+	}
+
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if obj := r.object(fun); obj != nil && obj.Kind == ast.Fun {
+			if decl, ok := obj.Decl.(*ast.FuncDecl); ok {
+				return decl.Type
+			}
+		}
+	case *ast.SelectorExpr:
+		if r.index == nil || r.isPackage(fun.X) {
+			return nil
+		}
+		for _, recv := range r.receivers(r.exprType(fun.X)) {
+			if decl := r.index.methods[recv+"."+fun.Sel.Name]; decl != nil {
+				return decl.Type
+			}
+		}
+	}
+
+	return nil
+}
+
+// field returns the struct field that sel selects, or nil if it does not
+// select a field or the type of its operand is not known.
+func (r *resolver) field(sel *ast.SelectorExpr) *ast.Field {
+	if r.isPackage(sel.X) {
+		return nil
+	}
+
+	return r.structField(r.exprType(sel.X), sel.Sel.Name, make(map[*ast.TypeSpec]bool))
+}
+
+// structField returns the field name of the struct type typ or of its
+// embedded fields. visited holds the types already searched.
+func (r *resolver) structField(typ ast.Expr, name string, visited map[*ast.TypeSpec]bool) *ast.Field {
+	if spec := r.typeSpec(typ); spec != nil {
+		if visited[spec] {
+			return nil
+		}
+		visited[spec] = true
+	}
+
+	st, ok := r.underlying(typ).(*ast.StructType)
+	if ptr, isPtr := r.underlying(typ).(*ast.StarExpr); isPtr {
+		st, ok = r.underlying(ptr.X).(*ast.StructType)
+	}
+	if !ok {
+		return nil
+	}
+
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return field
+			}
+		}
+		if len(field.Names) == 0 {
+			if ident := typeIdent(field.Type); ident != nil && ident.Name == name {
+				return field
+			}
+		}
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			if found := r.structField(field.Type, name, visited); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// receivers returns the names of the type typ and of the types embedded in
+// it, whose methods can be called on a value of type typ. It returns nil if
+// typ is not a type declared in the package.
+func (r *resolver) receivers(typ ast.Expr) (names []string) {
+	spec := r.typeSpec(typ)
+	if spec == nil {
+		return nil
+	}
+
+	specs := []*ast.TypeSpec{spec}
+	visited := map[*ast.TypeSpec]bool{spec: true}
+	for i := 0; i < len(specs); i++ {
+		names = append(names, specs[i].Name.Name)
+
+		var fields []*ast.Field
+		switch t := r.underlying(specs[i].Type).(type) {
+		case *ast.StructType:
+			fields = t.Fields.List
+		case *ast.InterfaceType:
+			fields = t.Methods.List
+		}
+		for _, field := range fields {
+			if embedded := r.typeSpec(field.Type); len(field.Names) == 0 && embedded != nil && !visited[embedded] {
+				visited[embedded] = true
+				specs = append(specs, embedded)
+			}
+		}
+	}
+
+	return
+}
+
+// CalleeType returns the func type that the function or method called by
+// call is declared with, or that the func value it calls has, if the
+// declarations of the package show it. file is the file of call, which must
+// belong to the package of x. It returns nil otherwise.
+func (x *Index) CalleeType(file *ast.File, call *ast.CallExpr) *ast.FuncType {
+	return x.resolver(file.Imports).calleeType(call)
+}
+
+// FuncValueType returns the func type of fun if it is a func value, such as
+// a variable, a parameter or a struct field, of a type that the
+// declarations of the package show. file is the file of fun, which must
+// belong to the package of x. It returns nil otherwise.
+func (x *Index) FuncValueType(file *ast.File, fun ast.Expr) *ast.FuncType {
+	typ, _ := x.resolver(file.Imports).funcValueType(fun)
+
+	return typ
+}
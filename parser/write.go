@@ -1,128 +1,855 @@
 package parser
 
 import (
-  "fmt"
+	"fmt"
 	"go/ast"
 	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// A Config controls how RenderFile renders a file.
+type Config struct {
+	// LineDirectives adds a line directive wherever the position of the
+	// output differs from the position in the named source, so that the
+	// compiler, stack traces, coverage and debuggers refer to the named
+	// source instead of the generated file.
+	LineDirectives bool
+
+	// Index holds the declarations with named parameters of the package,
+	// so that calls can pass named arguments in any order. Without it, the
+	// labels of a call must be in the order of the declaration.
+	Index *Index
+
+	// Eject renders plain Go that cannot be undone: the labels and default
+	// values of the parameters are not kept in comments, func types are not
+	// marked with NamedFuncTypeMarker and no helpers are added. The calls
+	// that leave out a parameter pass its default value as it is written,
+	// and hoisted calls write the types of their parameters, so the file of
+	// a call may need imports of the file of the declaration.
+	Eject bool
+}
+
+// HelpersMarker is the comment that precedes the declarations added to the
+// end of the translation of a file for the calls of its functions from other
+// files: the functions returning the default values of their parameters,
+// the aliases of their types, and the overloads that other packages call.
+const HelpersMarker = "//named:helpers"
+
+// NamedFuncTypeMarker is the comment that follows the func keyword of a func
+// type or function literal with named parameters in the translation. The
+// parameters of a declaration are shown by its mangled name instead.
+const NamedFuncTypeMarker = "/*named*/"
+
+// An outputFile renders an AST by writing every token at the position it
+// has in the source. Tokens that have no position in the AST are written
+// directly after the previous token.
 type outputFile struct {
-	out string
+	out          []byte
 	line, column int
-	fileSet *token.FileSet
+	fileSet      *token.FileSet
+
+	// The source position that corresponds to the current output position.
+	// The source column is column+1+columnOffset. Both only diverge from the
+	// output position when the output grows longer than the source.
+	sourceLine, columnOffset int
+	lineDirectives           bool
+	eject                    bool
+	resolver                 *resolver
+
+	indenting bool // only whitespace has been written on the current line
+
+	comments []*ast.Comment // comments that have not been written yet
+}
+
+func (f *outputFile) position(pos token.Pos) token.Position {
+	return f.fileSet.Position(pos)
 }
 
-func (f *outputFile) writeAfterFirst(str string, pos token.Pos, first *bool) {
-  if *first {
-    f.writeAt(str, pos)
-  }
-  *first = false
+// sameLine reports whether the source positions a and b are on the same
+// line.
+func (f *outputFile) sameLine(a, b token.Pos) bool {
+	return a.IsValid() && b.IsValid() && f.position(a).Line == f.position(b).Line
 }
 
 func (f *outputFile) writeAt(obj interface{}, pos token.Pos) {
-  position := f.fileSet.Position(pos)
+	if pos.IsValid() {
+		f.writeComments(pos)
+	}
+
+	position := f.position(pos)
+	f.pad(position)
+	f.writeLineDirectiveAt(position)
+	f.write(obj)
+}
+
+// writeComments writes the comments that come before pos in the source, at
+// their positions.
+func (f *outputFile) writeComments(pos token.Pos) {
+	for len(f.comments) > 0 && f.comments[0].Pos() < pos {
+		comment := f.comments[0]
+		f.comments = f.comments[1:]
+
+		position := f.position(comment.Pos())
+		f.pad(position)
+		f.writeLineDirectiveAt(position)
+		f.writeToken(comment.Text)
+		if strings.HasPrefix(comment.Text, "//") {
+			// The line ends with the comment, even if a reordered
+			// argument from an earlier line is written next.
+			f.writeRaw("\n")
+		}
+	}
+}
+
+// writeLineDirectiveAt writes a line directive if the next character
+// written would not be at position in the source.
+func (f *outputFile) writeLineDirectiveAt(position token.Position) {
+	if f.lineDirectives && position.Line > 0 && position.Filename != "" &&
+		(f.sourceLine != position.Line || f.column+1+f.columnOffset != position.Column) {
+		f.writeLineDirective(position)
+	}
+}
+
+// pad moves the output to position by writing newlines and whitespace. The
+// indentation of a line is written with tabs, and the rest with spaces, as
+// gofmt does. The column of a tab is one, so positions are kept either way.
+func (f *outputFile) pad(position token.Position) {
+	for f.sourceLine < position.Line {
+		f.writeRaw("\n")
+	}
+	for position.Line > 0 && f.column+1+f.columnOffset < position.Column {
+		if f.indenting {
+			f.writeRaw("\t")
+		} else {
+			f.writeRaw(" ")
+		}
+	}
+}
+
+// lineFilename returns the name of the source file used in line directives.
+// A relative name would be resolved from the directory of the output, which
+// is written next to the source, so only its base name is used.
+func lineFilename(filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+
+	return filepath.Base(filename)
+}
+
+// writeLineDirective maps the next character written to position.
+func (f *outputFile) writeLineDirective(position token.Position) {
+	f.write(fmt.Sprintf("/*line %s:%d:%d*/", lineFilename(position.Filename), position.Line, position.Column))
+	f.sourceLine = position.Line
+	f.columnOffset = position.Column - (f.column + 1)
+}
+
+// writeRaw appends str to the output. Tokens that span several lines, such
+// as raw strings, are copied from the source, so the output and source line
+// advance together.
+func (f *outputFile) writeRaw(str string) {
+	f.out = append(f.out, str...)
+	if i := strings.LastIndex(str, "\n"); i >= 0 {
+		lines := strings.Count(str, "\n")
+		f.line += lines
+		f.sourceLine += lines
+		f.column = len(str) - i - 1
+		f.columnOffset = 0
+		f.indenting = strings.TrimLeft(str[i+1:], " \t") == ""
+	} else {
+		f.column += len(str)
+		f.indenting = f.indenting && strings.TrimLeft(str, " \t") == ""
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// joinsOperator reports whether an operator or comment starts with the
+// characters a and b, so that they must not be written next to each other.
+func joinsOperator(a, b rune) bool {
+	pair := string(a) + string(b)
+	if pair == "//" || pair == "/*" {
+		return true
+	}
+
+	for tok := token.ILLEGAL; tok <= token.VAR; tok++ {
+		if tok.IsOperator() && strings.HasPrefix(tok.String(), pair) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeToken writes str, separated by a space from the previous token if
+// the two would otherwise be scanned as one token, or as a comment. This
+// happens when the output has grown longer than the source, so that the
+// positions no longer leave room between tokens.
+func (f *outputFile) writeToken(str string) {
+	last, _ := utf8.DecodeLastRune(f.out)
+	first, _ := utf8.DecodeRuneInString(str)
+	if len(f.out) > 0 && len(str) > 0 &&
+		(isIdentRune(last) && isIdentRune(first) || joinsOperator(last, first)) {
+		f.writeRaw(" ")
+	}
+
+	f.writeRaw(str)
+}
+
+// writeBefore writes tok, which has no position in the AST, as if it was
+// followed by a single space and next. This is how gofmt separates keywords
+// and assignments from the expression that follows them.
+func (f *outputFile) writeBefore(tok token.Token, next token.Pos) {
+	position := f.position(next)
+	if f.sourceLine == position.Line {
+		position.Column -= len(tok.String()) + 1
+		f.pad(position)
+	}
+
+	f.write(tok)
+}
+
+// writeSemi writes a semicolon between two nodes that are on the same line,
+// where the newline would otherwise have terminated the first one.
+func (f *outputFile) writeSemi(prev, next token.Pos) {
+	if f.sameLine(prev, next) {
+		f.write(";")
+	}
+}
+
+// writeTrailingComma writes a comma if closing, which closes a list that
+// is not empty, is written on a later line of the output than the last
+// element, as it is required there. The output decides rather than the
+// source, since the arguments of a call may be written in another order.
+func (f *outputFile) writeTrailingComma(closing token.Pos) {
+	if closing.IsValid() && f.sourceLine < f.position(closing).Line {
+		f.write(",")
+	}
+}
+
+// writeExprList writes the elements of list separated by commas. The list is
+// closed at closing, which may be followed by a trailing comma.
+func (f *outputFile) writeExprList(list []ast.Expr, closing token.Pos) {
+	for i, x := range list {
+		if i > 0 {
+			f.write(",")
+		}
+		f.write(x)
+	}
+
+	if len(list) > 0 {
+		f.writeTrailingComma(closing)
+	}
+}
+
+func (f *outputFile) writeIdentList(list []*ast.Ident) {
+	for i, x := range list {
+		if i > 0 {
+			f.write(",")
+		}
+		f.write(x)
+	}
+}
+
+func (f *outputFile) writeStmtList(list []ast.Stmt) {
+	for i, stmt := range list {
+		if i > 0 {
+			f.writeSemi(list[i-1].End(), stmt.Pos())
+		}
+		f.write(stmt)
+	}
+}
+
+// writeParams writes a parameter or result list. Results that are a single
+// unnamed type have no parentheses.
+func (f *outputFile) writeParams(list *ast.FieldList) {
+	if list == nil {
+		return
+	}
+
+	if list.Opening.IsValid() {
+		f.writeAt("(", list.Opening)
+	}
+	for i, field := range list.List {
+		if i > 0 {
+			f.write(",")
+		}
+		f.write(field)
+	}
+	if len(list.List) > 0 {
+		f.writeTrailingComma(list.Closing)
+	}
+	if list.Closing.IsValid() {
+		f.writeAt(")", list.Closing)
+	}
+}
+
+// writeTypeParams writes the type parameters of a type or function.
+func (f *outputFile) writeTypeParams(list *ast.FieldList) {
+	if list == nil {
+		return
+	}
+
+	f.writeAt("[", list.Opening)
+	for i, field := range list.List {
+		if i > 0 {
+			f.write(",")
+		}
+		f.write(field)
+	}
+	f.writeTrailingComma(list.Closing)
+	f.writeAt("]", list.Closing)
+}
+
+// writeFields writes the fields of a struct or the methods of an interface.
+func (f *outputFile) writeFields(list *ast.FieldList) {
+	f.writeAt("{", list.Opening)
+	for i, field := range list.List {
+		if i > 0 {
+			f.writeSemi(list.List[i-1].End(), field.Pos())
+		}
+		f.write(field)
+	}
+	f.writeAt("}", list.Closing)
+}
+
+// writeSignature writes the type parameters, parameters and results of a
+// function type, but not the func keyword.
+func (f *outputFile) writeSignature(o *ast.FuncType) {
+	f.writeTypeParams(o.TypeParams)
+	f.writeParams(o.Params)
+	f.writeParams(o.Results)
+}
+
+// writeCallName writes the function of a call. The labels are appended to
+// the name, in the same way that the name of a declaration with named
+// parameters is mangled by the parser.
+func (f *outputFile) writeCallName(o *ast.CallExpr, labels []string) {
+	f.write(o.Fun)
+	f.writeRaw(f.mangledSuffix(o, labels))
+}
 
-	for f.line < position.Line {
-		f.out += "\n"
-		f.line++
-		f.column = 0
+// writeArgs writes the arguments of a call, in the order of labels if the
+// call has named arguments, and the closing parenthesis. The parameters of
+// typ that the call leaves out are passed their default values.
+func (f *outputFile) writeArgs(o *ast.CallExpr, labels []string, typ *ast.FuncType) {
+	args := o.Args
+	defaults := make(map[ast.Expr]string)
+	if len(labels) > 0 {
+		groups := namedArgGroups(o)
+		args = nil
+		for _, label := range labels {
+			if def := paramDefault(typ, label); def != nil && len(groups[label]) == 0 {
+				args = append(args, def)
+				defaults[def] = f.defaultValue(typ, label)
+			}
+			args = append(args, groups[label]...)
+		}
 	}
-	for f.column < position.Column {
-		f.out += " "
-		f.column++
+
+	for i, arg := range args {
+		if i > 0 {
+			f.write(",")
+		}
+		if def, ok := defaults[arg]; ok {
+			f.writeToken(def)
+			continue
+		}
+		f.write(arg)
 	}
 
-  f.write(obj)
+	if o.Ellipsis.IsValid() {
+		f.writeAt("...", o.Ellipsis)
+	}
+	if len(args) > 0 {
+		f.writeTrailingComma(o.Rparen)
+	}
+	f.writeAt(")", o.Rparen)
 }
 
 func (f *outputFile) write(obj interface{}) {
-  first := true
-
-  switch o := obj.(type) {
-  case token.Token:
-    f.write(o.String())
-
-  case string:
-  	f.out += o
-  	f.column += len(o)
-
-  case *ast.Ident:
-    f.writeAt(o.Name, o.NamePos)
-
-  case *ast.FuncDecl:
-  	f.writeAt("func", o.Type.Func)
-  	f.writeAt(o.Name.Name, o.Name.NamePos)
-  	f.write(o.Type.Params)
-  	f.write(o.Body)
-
-  case *ast.FieldList:
-  	f.writeAt("(", o.Opening)
-  	for _, field := range o.List {
-  		f.write(field)
-      f.writeAfterFirst(",", o.Opening, &first)
-  	}
-    f.writeAt(")", o.Closing)
-
-  case *ast.Field:
-    for _, name := range o.Names {
-      f.write(name)
-
-      // TODO: Document this. The space is important for named arguments.
-      f.write(" ")
-    }
-
-    f.write(o.Type)
-
-  case *ast.BlockStmt:
-  	f.writeAt("{", o.Lbrace)
-    for _, stmt := range o.List {
-      f.write(stmt)
-    }
-    f.writeAt("}", o.Rbrace)
-
-  case *ast.ExprStmt:
-    f.write(o.X)
-
-  case *ast.CallExpr:
-    f.write(o.Fun)
-    for _, arg := range o.Args {
-      if a := arg.(*ast.BinaryExpr); a != nil {
-        f.write("_" + a.X.(*ast.Ident).Name)
-      }
-    }
-
-  	f.writeAt("(", o.Lparen)
-    for _, arg := range o.Args {
-      f.write(arg)
-      f.writeAfterFirst(",", o.Lparen, &first)
-    }
-    f.writeAt(")", o.Rparen)
-
-  case *ast.BinaryExpr:
-    if o.Op != token.COLON {
-      f.write(o.X)
-      f.writeAt(o.Op, o.OpPos)
-    }
-    f.write(o.Y)
-
-  case *ast.BasicLit:
-    f.writeAt(o.Value, o.ValuePos)
-
-  default:
-    panic(fmt.Sprintf("%#v", o))
-  }
+	switch o := obj.(type) {
+	case token.Token:
+		f.write(o.String())
+
+	case string:
+		f.writeToken(o)
+
+	// Comments and fields
+
+	case *ast.Field:
+		if label, ok := o.Type.(*ParamLabel); ok && label.Label != nil && !f.eject {
+			// The label is kept in a comment, so that it can be restored
+			// by undo.
+			f.writeAt("/*"+label.Label.Name+"*/", label.Label.Pos())
+			f.writeRaw(" ")
+		}
+		f.writeIdentList(o.Names)
+		f.write(o.Type)
+		if o.Tag != nil {
+			f.write(o.Tag)
+		}
+
+	// Expressions
+
+	case *ast.BadExpr:
+		// the parser has already reported an error
+
+	case *ast.Ident:
+		f.writeAt(o.Name, o.NamePos)
+
+	case *ast.BasicLit:
+		f.writeAt(o.Value, o.ValuePos)
+
+	case *ast.Ellipsis:
+		f.writeAt("...", o.Ellipsis)
+		if o.Elt != nil {
+			f.write(o.Elt)
+		}
+
+	case *ast.FuncLit:
+		f.write(o.Type)
+		f.write(o.Body)
+
+	case *ast.CompositeLit:
+		if o.Type != nil {
+			f.write(o.Type)
+		}
+		f.writeAt("{", o.Lbrace)
+		f.writeExprList(o.Elts, o.Rbrace)
+		f.writeAt("}", o.Rbrace)
+
+	case *ast.ParenExpr:
+		f.writeAt("(", o.Lparen)
+		f.write(o.X)
+		f.writeAt(")", o.Rparen)
+
+	case *ast.SelectorExpr:
+		f.write(o.X)
+		f.write(".")
+		f.write(o.Sel)
+
+	case *ast.IndexExpr:
+		f.write(o.X)
+		f.writeAt("[", o.Lbrack)
+		f.write(o.Index)
+		f.writeAt("]", o.Rbrack)
+
+	case *ast.IndexListExpr:
+		f.write(o.X)
+		f.writeAt("[", o.Lbrack)
+		for i, index := range o.Indices {
+			if i > 0 {
+				f.write(",")
+			}
+			f.write(index)
+		}
+		f.writeAt("]", o.Rbrack)
+
+	case *ast.SliceExpr:
+		f.write(o.X)
+		f.writeAt("[", o.Lbrack)
+		if o.Low != nil {
+			f.write(o.Low)
+		}
+		f.write(":")
+		if o.High != nil {
+			f.write(o.High)
+		}
+		if o.Slice3 {
+			f.write(":")
+			f.write(o.Max)
+		}
+		f.writeAt("]", o.Rbrack)
+
+	case *ast.TypeAssertExpr:
+		f.write(o.X)
+		f.write(".")
+		f.writeAt("(", o.Lparen)
+		if o.Type != nil {
+			f.write(o.Type)
+		} else {
+			f.write(token.TYPE)
+		}
+		f.writeAt(")", o.Rparen)
+
+	case *ast.CallExpr:
+		labels, typ := f.declaredLabels(o)
+		if typ != nil && f.resolver.reordersSideEffects(o, labels) {
+			f.writeHoisted(o, labels, typ)
+			break
+		}
+		f.writeCallName(o, labels)
+		f.writeAt("(", o.Lparen)
+		f.writeArgs(o, labels, typ)
+
+	case *ast.StarExpr:
+		f.writeAt("*", o.Star)
+		f.write(o.X)
+
+	case *ast.UnaryExpr:
+		f.writeAt(o.Op, o.OpPos)
+		f.write(o.X)
+
+	case *ast.BinaryExpr:
+		f.write(o.X)
+		f.writeAt(o.Op, o.OpPos)
+		f.write(o.Y)
+
+	case *ast.KeyValueExpr:
+		f.write(o.Key)
+		f.writeAt(":", o.Colon)
+		f.write(o.Value)
+
+	case *ParamLabel:
+		// the label is written before the names of the field
+		f.write(o.Type)
+
+	case *DefaultValue:
+		// The calls that leave out the parameter pass the value instead.
+		// It is kept in a comment, so that it can be restored by undo.
+		f.write(o.Type)
+		if !f.eject {
+			f.writeAt("/*= "+escapeComment(f.nodeString(o.Value))+"*/", o.Assign)
+		}
+
+	case *NamedArg:
+		// The label is left out, but it still ends the indentation of its
+		// line, so the value is aligned with spaces.
+		f.writeComments(o.Label.Pos())
+		f.pad(f.position(o.Label.Pos()))
+		f.indenting = false
+		f.write(o.Value)
+
+	// Types
+
+	case *ast.ArrayType:
+		f.writeAt("[", o.Lbrack)
+		if o.Len != nil {
+			f.write(o.Len)
+		}
+		f.write("]")
+		f.write(o.Elt)
+
+	case *ast.StructType:
+		f.writeAt(token.STRUCT, o.Struct)
+		f.writeFields(o.Fields)
+
+	case *ast.FuncType:
+		if o.Func.IsValid() {
+			f.writeAt(token.FUNC, o.Func)
+			if isLabelled(o) && !f.eject {
+				// The name of a func type is not mangled, so undo needs
+				// another way to tell that it has named parameters.
+				f.write(NamedFuncTypeMarker)
+			}
+		}
+		f.writeSignature(o)
+
+	case *ast.InterfaceType:
+		f.writeAt(token.INTERFACE, o.Interface)
+		f.writeFields(o.Methods)
+
+	case *ast.MapType:
+		f.writeAt(token.MAP, o.Map)
+		f.write("[")
+		f.write(o.Key)
+		f.write("]")
+		f.write(o.Value)
+
+	case *ast.ChanType:
+		switch o.Dir {
+		case ast.SEND:
+			f.writeAt(token.CHAN, o.Begin)
+			f.writeAt(token.ARROW, o.Arrow)
+		case ast.RECV:
+			f.writeAt(token.ARROW, o.Begin)
+			f.write(token.CHAN)
+		default:
+			f.writeAt(token.CHAN, o.Begin)
+		}
+		f.write(o.Value)
+
+	// Statements
+
+	case *ast.BadStmt:
+		// the parser has already reported an error
+
+	case *ast.DeclStmt:
+		f.write(o.Decl)
+
+	case *ast.EmptyStmt:
+		f.writeAt(";", o.Semicolon)
+
+	case *ast.LabeledStmt:
+		f.write(o.Label)
+		f.writeAt(":", o.Colon)
+		f.write(o.Stmt)
+
+	case *ast.ExprStmt:
+		f.write(o.X)
+
+	case *ast.SendStmt:
+		f.write(o.Chan)
+		f.writeAt(token.ARROW, o.Arrow)
+		f.write(o.Value)
+
+	case *ast.IncDecStmt:
+		f.write(o.X)
+		f.writeAt(o.Tok, o.TokPos)
+
+	case *ast.AssignStmt:
+		f.writeExprList(o.Lhs, token.NoPos)
+		f.writeAt(o.Tok, o.TokPos)
+		f.writeExprList(o.Rhs, token.NoPos)
+
+	case *ast.GoStmt:
+		f.writeAt(token.GO, o.Go)
+		f.write(o.Call)
+
+	case *ast.DeferStmt:
+		f.writeAt(token.DEFER, o.Defer)
+		f.write(o.Call)
+
+	case *ast.ReturnStmt:
+		f.writeAt(token.RETURN, o.Return)
+		f.writeExprList(o.Results, token.NoPos)
+
+	case *ast.BranchStmt:
+		f.writeAt(o.Tok, o.TokPos)
+		if o.Label != nil {
+			f.write(o.Label)
+		}
+
+	case *ast.BlockStmt:
+		f.writeAt("{", o.Lbrace)
+		f.writeStmtList(o.List)
+		f.writeAt("}", o.Rbrace)
+
+	case *ast.IfStmt:
+		f.writeAt(token.IF, o.If)
+		if o.Init != nil {
+			f.write(o.Init)
+			f.write(";")
+		}
+		f.write(o.Cond)
+		f.write(o.Body)
+		if o.Else != nil {
+			f.writeBefore(token.ELSE, o.Else.Pos())
+			f.write(o.Else)
+		}
+
+	case *ast.CaseClause:
+		if o.List != nil {
+			f.writeAt(token.CASE, o.Case)
+			f.writeExprList(o.List, token.NoPos)
+		} else {
+			f.writeAt(token.DEFAULT, o.Case)
+		}
+		f.writeAt(":", o.Colon)
+		f.writeStmtList(o.Body)
+
+	case *ast.SwitchStmt:
+		f.writeAt(token.SWITCH, o.Switch)
+		if o.Init != nil {
+			f.write(o.Init)
+			f.write(";")
+		}
+		if o.Tag != nil {
+			f.write(o.Tag)
+		}
+		f.write(o.Body)
+
+	case *ast.TypeSwitchStmt:
+		f.writeAt(token.SWITCH, o.Switch)
+		if o.Init != nil {
+			f.write(o.Init)
+			f.write(";")
+		}
+		f.write(o.Assign)
+		f.write(o.Body)
+
+	case *ast.CommClause:
+		if o.Comm != nil {
+			f.writeAt(token.CASE, o.Case)
+			f.write(o.Comm)
+		} else {
+			f.writeAt(token.DEFAULT, o.Case)
+		}
+		f.writeAt(":", o.Colon)
+		f.writeStmtList(o.Body)
+
+	case *ast.SelectStmt:
+		f.writeAt(token.SELECT, o.Select)
+		f.write(o.Body)
+
+	case *ast.ForStmt:
+		f.writeAt(token.FOR, o.For)
+		if o.Init != nil || o.Post != nil {
+			if o.Init != nil {
+				f.write(o.Init)
+			}
+			f.write(";")
+			if o.Cond != nil {
+				f.write(o.Cond)
+			}
+			f.write(";")
+			if o.Post != nil {
+				f.write(o.Post)
+			}
+		} else if o.Cond != nil {
+			f.write(o.Cond)
+		}
+		f.write(o.Body)
+
+	case *ast.RangeStmt:
+		f.writeAt(token.FOR, o.For)
+		if o.Key != nil {
+			f.write(o.Key)
+			if o.Value != nil {
+				f.write(",")
+				f.write(o.Value)
+			}
+			f.writeAt(o.Tok, o.TokPos)
+		}
+		f.writeBefore(token.RANGE, o.X.Pos())
+		f.write(o.X)
+		f.write(o.Body)
+
+	// Declarations
+
+	case *ast.ImportSpec:
+		if o.Name != nil {
+			f.write(o.Name)
+		}
+		f.write(o.Path)
+
+	case *ast.ValueSpec:
+		f.writeIdentList(o.Names)
+		if o.Type != nil {
+			f.write(o.Type)
+		}
+		if o.Values != nil {
+			f.writeBefore(token.ASSIGN, o.Values[0].Pos())
+			f.writeExprList(o.Values, token.NoPos)
+		}
+
+	case *ast.TypeSpec:
+		f.write(o.Name)
+		f.writeTypeParams(o.TypeParams)
+		if o.Assign.IsValid() {
+			f.writeAt("=", o.Assign)
+		}
+		f.write(o.Type)
+
+	case *ast.BadDecl:
+		// the parser has already reported an error
+
+	case *ast.GenDecl:
+		f.writeAt(o.Tok, o.TokPos)
+		if o.Lparen.IsValid() {
+			f.writeAt("(", o.Lparen)
+		}
+		for i, spec := range o.Specs {
+			if i > 0 {
+				f.writeSemi(o.Specs[i-1].End(), spec.Pos())
+			}
+			f.write(spec)
+		}
+		if o.Rparen.IsValid() {
+			f.writeAt(")", o.Rparen)
+		}
+
+	case *ast.FuncDecl:
+		f.writeAt(token.FUNC, o.Type.Func)
+		f.writeParams(o.Recv)
+		f.write(o.Name)
+		f.writeSignature(o.Type)
+		if o.Body != nil {
+			f.write(o.Body)
+		}
+
+	default:
+		panic(fmt.Sprintf("parser.RenderFile: unexpected node type %T", o))
+	}
 }
 
+// RenderFile renders file as plain Go, using the default Config.
 func RenderFile(file *ast.File, fileSet *token.FileSet) string {
+	return (&Config{}).RenderFile(file, fileSet)
+}
+
+// RenderFile renders file as plain Go. Declarations and calls with named
+// parameters are translated and everything else is kept at the position it
+// has in the source. Comments are only written if file was parsed with
+// ParseComments. A plain Go file may also be parsed by go/parser, so type
+// parameters and alias declarations are rendered, although the parser of
+// this package does not accept them.
+func (cfg *Config) RenderFile(file *ast.File, fileSet *token.FileSet) string {
 	f := new(outputFile)
 	f.fileSet = fileSet
+	f.line, f.sourceLine = 1, 1
+	f.indenting = true
+	f.lineDirectives = cfg.LineDirectives
+	f.eject = cfg.Eject
+	f.resolver = cfg.Index.resolver(file.Imports)
+	for _, group := range file.Comments {
+		f.comments = append(f.comments, group.List...)
+	}
 
-	f.writeAt("package", file.Package)
+	f.writeAt(token.PACKAGE, file.Package)
 	f.write(file.Name)
 
+	prev := file.Name.End()
 	for _, decl := range file.Decls {
+		f.writeSemi(prev, decl.Pos())
 		f.write(decl)
+		prev = decl.End()
+	}
+	if tokFile := fileSet.File(file.Package); tokFile != nil {
+		f.writeComments(token.Pos(tokFile.Base() + tokFile.Size() + 1))
+	}
+	if f.column > 0 {
+		f.writeRaw("\n")
+	}
+	if !f.eject {
+		f.writeHelpers(file)
+	}
+
+	return string(f.out)
+}
+
+// writeHelpers writes the declarations that the calls of the func types
+// declared in file at package level need, after HelpersMarker. The
+// overloads of the exported functions need the Index.
+func (f *outputFile) writeHelpers(file *ast.File) {
+	marked := false
+	mark := func() {
+		if !marked {
+			f.writeRaw("\n" + HelpersMarker + "\n")
+			marked = true
+		}
 	}
 
-	return f.out
+	packageFuncTypes(file, func(name string, typ *ast.FuncType) {
+		defaultParams(typ, func(label string, def ast.Expr) {
+			mark()
+			f.writeDefaultValue(defaultValueName(name, label), paramType(typ, label), def)
+		})
+		importedTypes(name, typ, func(alias, _ string, t ast.Expr) {
+			mark()
+			f.writeTypeAlias(alias, t)
+		})
+		if x := f.resolver.index; x != nil {
+			x.overloads(name, typ, func(overload string, labels []string) {
+				mark()
+				f.writeOverload(name, overload, labels, typ)
+			})
+		}
+	})
 }
@@ -0,0 +1,62 @@
+// +build ignore
+
+package main
+
+// Collisions
+// ==========
+//
+// A mangled name must not be declared by anything else in its scope, since
+// the translation would not compile.
+
+func pair(a_b: int) {}
+
+func pair(a: int, b: int) {} // ERROR "mangled name pair_a_b of pair\(a, b\) is also the mangled name of pair\(a_b\) at collisions.go:11:6"
+
+func named14_a_b(a int, b int) int { return a + b }
+
+func named14(a: int, b: int) int { return a + b } // ERROR "mangled name named14_a_b of named14\(a, b\) collides with func named14_a_b at collisions.go:15:6"
+
+func twice(x: int) int { return 2 * x }
+
+func twice(x: int) int { return x + x } // ERROR "twice\(x\) redeclared, previously declared at collisions.go:19:6"
+
+var open_path = "/"
+
+const close_path = 1
+
+type read_n int
+
+func open(path: string) {}  // ERROR "collides with var open_path"
+func close(path: string) {} // ERROR "collides with const close_path"
+func read(n: int) {}        // ERROR "collides with type read_n"
+
+type file struct {
+	seek_offset int
+}
+
+func (f *file) seek(offset: int) {} // ERROR "mangled name seek_offset of seek\(offset\) collides with field seek_offset"
+
+func (f file) write_n(n int) {}
+
+func (f *file) write(n: int) {} // ERROR "collides with method write_n"
+
+// Methods of other types, and functions, are in other scopes.
+type buffer struct{}
+
+func (b *buffer) write(n: int) {}
+
+func write(n: int) {}
+
+type stream interface {
+	flush(force: bool) // ERROR "collides with method flush_force"
+	flush_force(bool)
+}
+
+type sink interface {
+	flush(force: bool)
+}
+
+// The functions returning default values are declared in the package too.
+func fetch_url_retries_default_retries() int { return 0 }
+
+func fetch(url: string, retries: int = 3) {} // ERROR "mangled name fetch_url_retries_default_retries of the default value of retries of fetch_url_retries collides with func fetch_url_retries_default_retries at collisions.go:60:6"
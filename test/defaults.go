@@ -0,0 +1,101 @@
+// +build ignore
+
+package main
+
+// Default values
+// ==============
+//
+// A named parameter can have a default value. A call that leaves out its
+// label passes the default value instead.
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultPort = 443
+
+func dial(host: string, port: int = defaultPort, timeout: time.Duration = 30 * time.Second) string {
+	return fmt.Sprintf("%s:%d %v", host, port, timeout)
+}
+
+type point struct {
+	x, y int
+}
+
+func (p point) move(dx: int = 0, dy: int = 0) point {
+	return point{p.x + dx, p.y + dy}
+}
+
+func scale(p: point, by: int = 2) point {
+	return point{p.x * by, p.y * by}
+}
+
+// The declaration is chosen by the labels of the call, so a default value
+// can stand in for an overload.
+func scale(p: point, x: int, y: int = 1) point {
+	return point{p.x * x, p.y * y}
+}
+
+// A default value can hold the end of a comment, which is escaped in the
+// comment that keeps it in the translation.
+func quote(s: string, open: string = "/*", close: string = "*/") string {
+	return open + s + close
+}
+
+// A call without arguments leaves out every parameter, if they all have
+// default values.
+func origin(x: int = 0, y: int = 0) point {
+	return point{x, y}
+}
+
+type size int64
+
+// Conversions and the builtins that only compute a value are pure.
+func buffer(n: size = size(len("abcd")) * 2) size {
+	return n
+}
+
+var trace []string
+
+func next(name string, value int) int {
+	trace = append(trace, name)
+	return value
+}
+
+func check(result, expectedResult interface{}) {
+	if result != expectedResult {
+		panic(fmt.Sprint("Failed! ", result, " != ", expectedResult))
+	}
+}
+
+func main() {
+	check(dial(host: "localhost"), "localhost:443 30s")
+	check(dial(host: "localhost", port: 80), "localhost:80 30s")
+	check(dial(host: "localhost", timeout: time.Second), "localhost:443 1s")
+	check(dial(timeout: time.Second, host: "localhost"), "localhost:443 1s")
+	check(dial(
+		host: "localhost",
+	), "localhost:443 30s")
+
+	p := point{1, 2}
+	check(p.move(dx: 1), point{2, 2})
+	check(p.move(dy: 1), point{1, 3})
+	check(p.move(dy: next("dy", 1), dx: next("dx", 1)), point{2, 3})
+	check(fmt.Sprint(trace), "[dy dx]")
+	check(p.move(), p)
+	check(origin(), point{0, 0})
+	check(origin(y: 1), point{0, 1})
+	check(buffer(), size(8))
+
+	check(scale(p: p), point{2, 4})
+	check(scale(p: p, by: 3), point{3, 6})
+	check(scale(p: p, x: 3), point{3, 2})
+
+	check(quote(s: "x"), "/*x*/")
+	check(quote(s: "x", close: `*\/`), `/*x*\/`)
+
+	double := func(x: int, factor: int = 2) int { return x * factor }
+	check(double(x: 4), 8)
+	check(double(factor: 3, x: 4), 12)
+}
@@ -0,0 +1,55 @@
+// +build ignore
+
+package main
+
+// Errors
+// ======
+//
+// Every line with an ERROR comment must be reported with a matching error.
+
+func add(x: int, y: int) int {
+	return x + y
+}
+
+func main() {
+	add(x: 1, 2)  // ERROR "cannot mix named and positional arguments"
+	add(1, y: 2)  // ERROR "cannot mix named and positional arguments"
+	add(1, add(x: 2, 3)) // ERROR "cannot mix named and positional arguments"
+	add(1, add(2, y: 3)) // ERROR "cannot mix named and positional arguments"
+
+	add(x: 1, y: 2)
+	println(add(x: 1, y: 2), 3)
+}
+
+func values(f: func(x: int)) {
+	f(y: 1) // ERROR "wrong labels \(y\) for f, want \(x\)"
+
+	plain := func(x int) {}
+	plain(x: 1) // ERROR "plain has no parameter labels"
+}
+
+func join(sep: string, parts: ...string) string {
+	return ""
+}
+
+func split(parts: ...string, sep: string) {} // ERROR "can only use ... with final parameter"
+
+func variadic() {
+	join(parts: "a", "b", sep: ",") // ERROR "variadic argument parts must be the last named argument"
+	join(sep: ",", parts: "a", "b")
+
+	f := func(x: int, rest: ...int) {}
+	f(x: 1, rest: 2, 3)
+	f(rest: 2, x: 1, 3) // ERROR "cannot mix named and positional arguments"
+}
+
+func resize(width: int, height: int = width) {} // ERROR "default value of height refers to parameter width"
+
+func tail(sep: string, parts: ...string = nil) {} // ERROR "variadic parameter cannot have a default value"
+
+func defaults(scale: func(x: int, factor: int = 2) int) {
+	scale(x: 1)
+	scale(factor: 3) // ERROR "wrong labels \(factor\) for scale, want \(x, factor\)"
+}
+
+func swap(a, b c: int) {} // ERROR "a parameter with a label must have a single name"
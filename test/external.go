@@ -0,0 +1,57 @@
+// +build ignore
+
+package main
+
+// External labels
+// ===============
+//
+// A parameter can have a label that is not its name. The label is used by
+// the calls, and in the mangled name, and the name is used in the body.
+
+import "fmt"
+
+func move(from src: string, to dst: string) string {
+	return src + "->" + dst
+}
+
+func copy(from src: string, to dst: string, times n: int = 1) string {
+	return fmt.Sprint(move(from: src, to: dst), " x", n)
+}
+
+type queue []int
+
+func (q *queue) push(value v: int) {
+	*q = append(*q, v)
+}
+
+func (q queue) sum(from start: int = 0, values: ...int) (s int) {
+	for _, v := range append(q[start:], values...) {
+		s += v
+	}
+	return
+}
+
+func check(result, expectedResult interface{}) {
+	if result != expectedResult {
+		panic(fmt.Sprint("Failed! ", result, " != ", expectedResult))
+	}
+}
+
+func main() {
+	check(move(from: "a", to: "b"), "a->b")
+	check(move(to: "b", from: "a"), "a->b")
+	check(copy(from: "a", to: "b"), "a->b x1")
+	check(copy(times: 2, from: "a", to: "b"), "a->b x2")
+
+	var q queue
+	q.push(value: 1)
+	q.push(value: 2)
+	check(q.sum(from: 1, values: 5), 7)
+	check(q.sum(values: 3, 4), 10)
+
+	from, to := "x", "y"
+	check(move(from:, to:), "x->y")
+
+	rename := func(old name: string, new: string) string { return name + "=" + new }
+	check(rename(new: "b", old: "a"), "a=b")
+}
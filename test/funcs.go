@@ -0,0 +1,77 @@
+// +build ignore
+
+package main
+
+// Func values
+// ===========
+//
+// Func types and literals may have named parameters. A call of a func value
+// keeps the name of the variable, and only drops the labels after they are
+// checked against the func type of the variable.
+
+type handler func(name: string, times: int) string
+
+var double func(x: int) int
+
+func apply(f: func(x: int) int, x: int) int {
+	return f(x: x)
+}
+
+func makeAdder(n: int) func(x: int) int {
+	return func(x: int) int {
+		return x + n
+	}
+}
+
+func subtract() func(x: int, y: int) int {
+	return func(x: int, y: int) int { return x - y }
+}
+
+type controller struct {
+	OnMove func(x: int, y: int) int
+	inner  *controller
+}
+
+func check(result, expectedResult int) {
+	if result != expectedResult {
+		panic("Failed!")
+	}
+}
+
+func main() {
+	double = func(x: int) int { return 2 * x }
+	check(double(x: 4), 8)
+	check(apply(f: double, x: 5), 10)
+
+	var h handler = func(name: string, times: int) string {
+		s := ""
+		for i := 0; i < times; i++ {
+			s += name
+		}
+		return s
+	}
+	check(len(h(name: "ab", times: 3)), 6)
+
+	add := func(a: int, b: int) int { return a + b }
+	check(add(a: 1, b: 2), 3)
+
+	// The type of a value is also known from the result type of the
+	// function that returns it, so its labels can be reordered.
+	inc := makeAdder(n: 1)
+	check(inc(x: 1), 2)
+	check(apply(f: makeAdder(n: 2), x: 1), 3)
+
+	cb := subtract()
+	check(cb(y: 1, x: 10), 9)
+	check(subtract()(y: 1, x: 10), 9)
+
+	// Struct fields are func values too.
+	c := controller{OnMove: func(x: int, y: int) int { return x*10 + y }}
+	c.inner = &c
+	check(c.OnMove(x: 1, y: 2), 12)
+	check(c.inner.OnMove(y: 2, x: 1), 12)
+
+	// A variable shadows the function of the same name.
+	apply := func(f: func(x: int) int, x: int) int { return f(x: x) + 1 }
+	check(apply(f: inc, x: 0), 2)
+}
@@ -0,0 +1,102 @@
+// +build ignore
+
+package main
+
+// Labels
+// ======
+//
+// Colons are only label separators inside the arguments of a call. Every
+// other use of a colon in a statement must be parsed exactly as go/parser
+// does, so this file is plain Go.
+
+func switches(x int, v interface{}) int {
+	switch x {
+	case 1:
+		return 1
+	case 2, 3:
+		x++
+		fallthrough
+	case x + 4:
+		return x + 4
+	default:
+	}
+
+	switch {
+	case x > 2:
+		return x
+	}
+
+	switch y := x * 2; y {
+	case 4:
+		return y
+	}
+
+	switch t := v.(type) {
+	case int:
+		return t
+	case string:
+		return len(t)
+	case []byte, nil:
+	default:
+		_ = t
+	}
+
+	switch v.(type) {
+	case map[string]int:
+	}
+
+	return 0
+}
+
+func selects(ch chan int, done chan bool) (n int) {
+	select {
+	case v := <-ch:
+		n = v
+	case v, ok := <-ch:
+		if ok {
+			n = v
+		}
+	case n = <-ch:
+	case <-done:
+	case ch <- 1:
+	default:
+	}
+
+	select {}
+}
+
+func labels(xs []int) int {
+	i := 0
+loop:
+	if i < len(xs) {
+		i++
+		goto loop
+	}
+
+outer:
+	for _, x := range xs {
+	inner:
+		for j := 0; j < x; j++ {
+			switch {
+			case j == 1:
+				continue inner
+			case j == 2:
+				continue outer
+			case j > 3:
+				break outer
+			}
+		}
+	}
+
+empty:
+	;
+	goto empty
+}
+
+func slices(xs []int, s string) {
+	_ = xs[1:]
+	_ = xs[:2]
+	_ = xs[1:2:3]
+	_ = s[len(xs):]
+	_ = map[string]int{"a": 1}[s]
+}
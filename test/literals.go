@@ -0,0 +1,59 @@
+// +build ignore
+
+package main
+
+// Composite literals
+// ==================
+//
+// Keys in composite literals are separated by colons too, but they must
+// always be parsed as key-value pairs and never as named arguments. This
+// file is plain Go so that it can be compared with go/parser.
+
+type point struct {
+	X, Y int
+}
+
+type line struct {
+	From, To point
+	Label    string
+}
+
+var (
+	origin = point{}
+	unit   = point{X: 1, Y: 1}
+	diag   = line{From: origin, To: point{X: 2, Y: 2}, Label: "diag"}
+	short  = line{point{}, point{1, 2}, ""}
+
+	names  = map[string]int{"a": 1, "b": 2}
+	nested = map[string][]point{
+		"square": {{X: 0, Y: 0}, {X: 1, Y: 0}, {1, 1}, {Y: 1}},
+	}
+	byPoint = map[point]string{{1, 2}: "a", {X: 3}: "b"}
+	ptrs    = []*point{{X: 1}, &point{Y: 2}}
+	indexed = [...]string{2: "c", 0: "a", 1: "b"}
+	sparse  = []int{5: 1, len("ab"): 2}
+	funcs   = map[string]func(int) int{
+		"double": func(x int) int { return x * 2 },
+	}
+	anon = struct {
+		Name string
+		Tags map[string]bool
+	}{Name: "x", Tags: map[string]bool{"y": true}}
+)
+
+func literals(p point, key string) []point {
+	if p == (point{X: 1}) {
+		return []point{p}
+	}
+
+	for _, q := range []point{{X: 1}, {Y: 1}} {
+		if q == p {
+			return nil
+		}
+	}
+
+	m := map[string]point{key: {X: len(key), Y: names[key]}}
+	m[key] = point{X: m[key].Y, Y: m[key].X}
+
+	return append(append([]point{}, point{X: p.Y, Y: p.X}, m[key]), nested["square"][1:2]...)
+}
@@ -0,0 +1,98 @@
+// +build ignore
+
+package main
+
+// Methods
+// =======
+//
+// Named parameters on methods are mangled like functions, and so are the
+// calls through selectors, whatever the receiver is.
+
+type point struct {
+	x, y int
+}
+
+func newPoint(x: int, y: int) *point {
+	return &point{x, y}
+}
+
+func (p *point) move(dx: int, dy: int) {
+	p.x += dx
+	p.y += dy
+}
+
+func (p point) distance(to: point) int {
+	return abs(p.x-to.x) + abs(p.y-to.y)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Methods of embedded fields are promoted with their mangled names.
+type sprite struct {
+	point
+	name string
+}
+
+type layer struct {
+	*sprite
+	sprites []sprite
+}
+
+// Interface methods are mangled too, so that the methods above implement
+// them.
+type mover interface {
+	move(dx: int, dy: int)
+}
+
+type measurer interface {
+	mover
+	distance(to: point) int
+}
+
+func check(result, expectedResult int) {
+	if result != expectedResult {
+		panic("Failed!")
+	}
+}
+
+func main() {
+	p := newPoint(x: 1, y: 2)
+	p.move(dx: 2, dy: 3)
+	check(p.x, 3)
+	check(p.distance(to: point{}), 8)
+
+	// value receiver through a pointer, pointer receiver through a value
+	var q point
+	check((*p).distance(to: q), 8)
+	q.move(dx: 1, dy: 1)
+	check(q.distance(to: *p), 6)
+
+	s := sprite{point: *p, name: "s"}
+	s.move(dx: -3, dy: -5)
+	check(s.distance(to: point{}), 0)
+	check(s.point.distance(to: point{1, 1}), 2)
+
+	l := layer{sprite: &s, sprites: []sprite{s}}
+	l.move(dx: 1, dy: 0)
+	check(s.x, 1)
+	l.sprites[0].move(dx: 0, dy: 4)
+	check(l.sprites[0].distance(to: s.point), 5)
+
+	check(newPoint(x: 5, y: 5).distance(to: *newPoint(x: 4, y: 4)), 2)
+
+	var m measurer = l
+	m.move(dx: -1, dy: 0)
+	check(m.distance(to: point{}), 0)
+	var mv mover = m
+	mv.move(dx: 2, dy: 0)
+	check(s.x, 2)
+
+	move := func(dx int) { l.sprite.move(dx: dx, dy: 0) }
+	move(2)
+	check(l.x, 4)
+}
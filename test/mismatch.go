@@ -0,0 +1,73 @@
+// +build ignore
+
+package main
+
+// Mismatched labels
+// =================
+//
+// A call that matches no declaration, or more than one, is reported with
+// the declarations that it could have meant.
+
+import "strings"
+
+func named14(a: int, b: int) int {
+	return a + b
+}
+
+func dial(host: string, port: int = 443, timeout: int = 30) {}
+
+func scale(x: int, by: int = 2) int { return x * by }
+
+func scale(x: int, times: int = 2) int { return x * times }
+
+type point struct{}
+
+func (p point) move(dx: int, dy: int) {}
+
+type cursor struct{}
+
+func (c cursor) move(x: int, y: int) {}
+
+func Repeat(str: string, n: int) string { return "" }
+
+func join(sep: string, parts: ...string) string { return "" }
+
+func main() {
+	named14(a: 1, b: 2)
+	named14(b: 2, a: 1)
+	named14(a: 1, c: 2) // ERROR "no overload of named14 with labels \(a, c\); candidates: \(a, b\); did you mean b instead of c\?"
+	named14(a: 1)       // ERROR "no overload of named14 with labels \(a\); candidates: \(a, b\)$"
+
+	dial(host: "localhost")
+	dial(host: "localhost", prot: 80)  // ERROR "did you mean port instead of prot\?"
+	dial(port: 80)                     // ERROR "no overload of dial with labels \(port\)"
+	dial()                             // ERROR "no overload of dial with labels \(\); candidates: \(host, port, timeout\)$"
+	dial(hots: "a", timeuot: 1)        // ERROR "did you mean host instead of hots, timeout instead of timeuot\?"
+	dial(host: "localhost", speed: 1)  // ERROR "candidates: \(host, port, timeout\)$"
+
+	scale(x: 1, by: 3)
+	scale(x: 1) // ERROR "ambiguous call of scale with labels \(x\); candidates: \(x, by\), \(x, times\)"
+
+	var p point
+	p.move(dx: 1, dy: 2)
+	p.move(dx: 1, dz: 2) // ERROR "no overload of move with labels \(dx, dz\); candidates: \(dx, dy\); did you mean dy instead of dz\?"
+	p.move(x: 1, y: 2)   // ERROR "no overload of move with labels \(x, y\); candidates: \(dx, dy\); did you mean dx instead of x, dy instead of y\?"
+
+	var c cursor
+	c.move(y: 2, x: 1)
+
+	// Functions of other packages are not checked, even if the package
+	// declares a function of the same name.
+	strings.Repeat(s: "a", count: 2)
+
+	// Positional values after the named arguments must belong to a variadic
+	// parameter, so the declaration has to be known.
+	join(sep: ",", parts: "a", "b")
+	join(sepp: ",", parts: "a", "b")  // ERROR "no overload of join with labels \(sepp, parts\); candidates: \(sep, parts\); did you mean sep instead of sepp\?"
+	concat(sep: ",", parts: "a", "b") // ERROR "cannot mix named and positional arguments, the declaration of concat is not known"
+	strings.Join(elems: nil, ",")     // ERROR "cannot mix named and positional arguments, the declaration of strings.Join is not known"
+
+	// The labels of a func value can only be checked if its type is known.
+	upper := strings.ToUpper
+	upper(s: "a") // ERROR "cannot check the labels of upper, its func type is not known"
+}
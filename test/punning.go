@@ -0,0 +1,46 @@
+// +build ignore
+
+package main
+
+// Punning
+// =======
+//
+// A label without a value passes the variable with the same name, so
+// connect(host:, port:) is the same as connect(host: host, port: port).
+
+import "fmt"
+
+func connect(host: string, port: int) string {
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+func join(sep: string, parts: ...string) (s string) {
+	for _, part := range parts {
+		s += part + sep
+	}
+	return
+}
+
+func check(result, expectedResult string) {
+	if result != expectedResult {
+		panic("Failed!")
+	}
+}
+
+func main() {
+	host, port := "localhost", 80
+	check(connect(host:, port:), "localhost:80")
+	check(connect(host:, port: 8080), "localhost:8080")
+	check(connect(
+		host:,
+		port:,
+	), "localhost:80")
+
+	sep, parts := "/", []string{"a", "b"}
+	check(join(sep:, parts:...), "a/b/")
+
+	// The variable is the one in scope at the call.
+	for _, port := range []int{1, 2} {
+		check(connect(host:, port:), fmt.Sprint("localhost:", port))
+	}
+}
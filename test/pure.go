@@ -0,0 +1,44 @@
+// +build ignore
+
+package main
+
+// Pure default values
+// ===================
+//
+// A default value is evaluated by every call that leaves it out, so it must
+// be constant or pure. Conversions and the builtins that only compute a
+// value from their arguments look like calls, but are pure.
+
+import "strings"
+
+type Port int
+
+func defaultPort() int {
+	return 443
+}
+
+var ready = make(chan int)
+
+func dial(host: string, port: int = defaultPort(), timeout: int = 30) {} // ERROR "default value of port must be constant or pure"
+
+func wait(n: int = <-ready) {} // ERROR "default value of n must be constant or pure"
+
+func grow(xs: []int = append([]int(nil), 1)) {} // ERROR "default value of xs must be constant or pure"
+
+func conv(port: Port = Port(443), size: int64 = int64(len("abc")), ptr: *Port = (*Port)(nil)) {}
+
+func builtins(n: int = max(len("ab"), cap([]int{})), c: float64 = real(complex(1, 2))) {}
+
+func qualified(s: string = strings.ToUpper("a")) {} // ERROR "default value of s must be constant or pure"
+
+func local() {
+	type T int
+	f := func(x: T = T(1), y: int = len(ready)) {}
+	f(x: 2)
+
+	len := func(s string) int { return 0 }
+	g := func(n: int = len("a")) {} // ERROR "default value of n must be constant or pure"
+	g(n: 1)
+}
+
+func main() {}
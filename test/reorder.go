@@ -0,0 +1,165 @@
+// +build ignore
+
+package main
+
+// Reordered labels
+// ================
+//
+// The labels of a call may be in any order. The call is matched with the
+// declaration that has the same labels, and the arguments are passed in the
+// order of the declaration. Arguments with side effects are still evaluated
+// from left to right.
+
+import "fmt"
+
+func sub(a: int, b: int) int {
+	return a - b
+}
+
+func sub(a: int, c: int) int {
+	return 10 * (a - c)
+}
+
+type account struct {
+	balance int
+}
+
+func (acc *account) transfer(amount: int, to: *account) (int, error) {
+	acc.balance -= amount
+	to.balance += amount
+	return acc.balance, nil
+}
+
+// Methods are matched with the declarations of the type of their receiver
+// only, so the methods of another type with the same labels in another order
+// are not candidates.
+type matrix struct{}
+
+func (matrix) at(row: int, col: int) int {
+	return 10*row + col
+}
+
+type transposed struct{}
+
+func (transposed) at(col: int, row: int) int {
+	return 10*col + row
+}
+
+func identity() matrix {
+	return matrix{}
+}
+
+// The parameters of the function literal cannot collide with the labels.
+type picker struct{}
+
+func (picker) pick(g: int, f: int) int {
+	return 10*g + f
+}
+
+func newPicker() picker {
+	trace = append(trace, "picker")
+	return picker{}
+}
+
+// Func types in the parameters keep their labels and default values.
+func apply(n: int, cb: func(to dst: int) int) int {
+	return cb(to: n)
+}
+
+func scale(n: int, by: func(x: int = 2) int) int {
+	return by(x: n)
+}
+
+func double() func(to dst: int) int {
+	trace = append(trace, "double")
+	return func(to dst: int) int { return 2 * dst }
+}
+
+func times() func(x: int = 2) int {
+	trace = append(trace, "times")
+	return func(x: int = 2) int { return 2 * x }
+}
+
+func join(sep: string, parts: ...string) string {
+	s := ""
+	for _, part := range parts {
+		s += sep + part
+	}
+	return s
+}
+
+var trace []string
+
+// next records that it was evaluated and returns n.
+func next(name string, n int) int {
+	trace = append(trace, name)
+	return n
+}
+
+func checkTrace(expected string) {
+	if fmt.Sprint(trace) != expected {
+		panic("Failed! " + fmt.Sprint(trace))
+	}
+	trace = nil
+}
+
+func check(result, expectedResult int) {
+	if result != expectedResult {
+		panic("Failed!")
+	}
+}
+
+func main() {
+	check(sub(b: 1, a: 3), 2)
+	check(sub(c: 1, a: 3), 20)
+	check(sub(
+		b: 5,
+		a: 7,
+	), 2)
+
+	// The last argument written comes from an earlier line than the
+	// closing parenthesis, so it needs a trailing comma.
+	check(sub(b: 1,
+		a: 2), 1)
+
+	// Only one argument has side effects, so it can be moved.
+	check(sub(b: next("b", 1), a: 3), 2)
+	checkTrace("[b]")
+
+	// Both have side effects, so they are evaluated in the order written.
+	check(sub(b: next("b", 1), a: next("a", 3)), 2)
+	checkTrace("[b a]")
+	check(sub(b: sub(b: next("b2", 1), a: next("a2", 2)), a: next("a", 3)), 2)
+	checkTrace("[b2 a2 a]")
+
+	acc, other := &account{balance: 10}, &account{}
+	balance, _ := acc.transfer(to: other, amount: 3)
+	check(balance, 7)
+
+	// The receiver is evaluated before the arguments too.
+	get := func() *account { trace = append(trace, "acc"); return acc }
+	balance, _ = get().transfer(to: []*account{other}[next("to", 0)], amount: next("amount", 2))
+	check(balance, 5)
+	check(other.balance, 5)
+	checkTrace("[acc to amount]")
+
+	var t transposed
+	check(t.at(row: 1, col: 2), 21)
+	check(identity().at(col: 2, row: 1), 12)
+
+	check(newPicker().pick(f: next("f", 1), g: next("g", 2)), 21)
+	checkTrace("[picker f g]")
+	check(apply(cb: double(), n: next("n", 3)), 6)
+	checkTrace("[double n]")
+	check(scale(by: times(), n: next("n", 3)), 6)
+	checkTrace("[times n]")
+
+	if join(parts: "a", sep: "/") != "/a" {
+		panic("Failed!")
+	}
+
+	diff := func(x: int, y: int) int { return x - y }
+	check(diff(y: 1, x: 2), 1)
+	check(diff(y: next("y", 1), x: next("x", 2)), 1)
+	checkTrace("[y x]")
+}
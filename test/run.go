@@ -0,0 +1,906 @@
+// +build ignore
+
+// Run checks the fixtures in this directory:
+//
+//	go run run.go [fixture.go ...]
+//
+// Without arguments every fixture is checked. Every fixture must parse, with
+// named arguments only in calls, and a fixture that is plain Go must be
+// parsed into the same AST as the one produced by the standard go/parser.
+//
+// The translation of every fixture must be plain Go that type-checks, each
+// fixture being a program of its own. A plain Go fixture must translate into
+// itself, comments included, and a fixture with an expected translation in
+// fixture_expected.txt must translate into exactly that. The translation
+// with line directives must type-check as well, and its identifiers must be
+// mapped onto the same identifiers of the fixture.
+//
+// A fixture with // ERROR "regexp" comments must instead fail, with exactly
+// one matching error on each line that has such a comment.
+//
+// Without arguments the commands of the tool are tested as well. The tool is
+// built from the parent directory and run on copies of the fixtures, each
+// test in a temporary directory of its own.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	goParser "go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"../parser"
+)
+
+// dump returns a line for each node of file, with its type, position and
+// the details that distinguish it from other nodes of the same type.
+func dump(fset *token.FileSet, file *ast.File) (lines []string) {
+	parser.Inspect(file, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+
+		line := fmt.Sprintf("%s: %T", fset.Position(node.Pos()), node)
+		switch n := node.(type) {
+		case *ast.Ident:
+			line += " " + n.Name
+		case *ast.BasicLit:
+			line += " " + n.Value
+		case *ast.BinaryExpr:
+			line += " " + n.Op.String()
+		case *ast.UnaryExpr:
+			line += " " + n.Op.String()
+		case *ast.AssignStmt:
+			line += " " + n.Tok.String()
+		case *ast.IncDecStmt:
+			line += " " + n.Tok.String()
+		case *ast.BranchStmt:
+			line += " " + n.Tok.String()
+		case *ast.GenDecl:
+			line += " " + n.Tok.String()
+		}
+		lines = append(lines, line)
+
+		return true
+	})
+
+	return
+}
+
+// checkNamedArgs reports a NamedArg that is not an argument of a call, for
+// example a key of a composite literal.
+func checkNamedArgs(fset *token.FileSet, file *ast.File) error {
+	args := make(map[ast.Expr]bool)
+	var err error
+	parser.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CallExpr:
+			for _, arg := range n.Args {
+				args[arg] = true
+			}
+		case *parser.NamedArg:
+			if !args[n] && err == nil {
+				err = fmt.Errorf("%s: named argument outside of a call", fset.Position(n.Pos()))
+			}
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// compareAST parses src and, if it is plain Go, reports the first difference
+// between its AST and the one produced by go/parser.
+func compareAST(filename string, src []byte) error {
+	fset := token.NewFileSet()
+	got, err := parser.ParseFile(fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	if err := checkNamedArgs(fset, got); err != nil {
+		return err
+	}
+
+	want, err := goParser.ParseFile(fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		// not plain Go, so there is nothing to compare with
+		return nil
+	}
+
+	if err := compareDumps(dump(fset, got), dump(fset, want)); err != nil {
+		return fmt.Errorf("go/parser: %s", err)
+	}
+
+	return nil
+}
+
+// compareDumps reports the first difference between the dumps of two ASTs.
+func compareDumps(gotLines, wantLines []string) error {
+	for i := range wantLines {
+		if i >= len(gotLines) {
+			return fmt.Errorf("missing node\n\twant %s", wantLines[i])
+		}
+		if gotLines[i] != wantLines[i] {
+			return fmt.Errorf("AST differs\n\tgot  %s\n\twant %s", gotLines[i], wantLines[i])
+		}
+	}
+	if len(gotLines) > len(wantLines) {
+		return fmt.Errorf("unexpected node\n\tgot %s", gotLines[len(wantLines)])
+	}
+
+	return nil
+}
+
+// typeCheck type-checks files as a package.
+func typeCheck(fset *token.FileSet, files []*ast.File) error {
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err := conf.Check(files[0].Name.Name, fset, files, nil)
+
+	return err
+}
+
+// compareRender translates src and checks the translation. Tokens and
+// comments keep their positions, so plain Go must translate into src.
+func compareRender(filename string, src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	cfg := &parser.Config{Index: parser.NewIndex([]*ast.File{file})}
+	out := cfg.RenderFile(file, fset)
+	translated, err := goParser.ParseFile(fset, filename, out, 0)
+	if err != nil {
+		return fmt.Errorf("translation is not plain Go: %s", err)
+	}
+
+	if err := typeCheck(fset, []*ast.File{translated}); err != nil {
+		return fmt.Errorf("translation does not type-check: %s", err)
+	}
+
+	if _, err := goParser.ParseFile(fset, filename, src, 0); err == nil && out != string(src) {
+		return fmt.Errorf("translation of plain Go differs from the source")
+	}
+
+	expected, err := ioutil.ReadFile(strings.TrimSuffix(filename, ".go") + "_expected.txt")
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if out != string(expected) {
+		return fmt.Errorf("translation differs from %s_expected.txt", strings.TrimSuffix(filename, ".go"))
+	}
+
+	return nil
+}
+
+// compareLineDirectives translates src with line directives, and checks
+// that the translation type-checks and that every identifier that a line
+// directive maps onto an identifier of src is that identifier, or its
+// mangled name. The function literals of hoisted calls, the default values
+// that calls pass and the helpers are written where nothing in src
+// corresponds to them, so they are left out.
+func compareLineDirectives(filename string, src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	cfg := &parser.Config{LineDirectives: true, Index: parser.NewIndex([]*ast.File{file})}
+	out := cfg.RenderFile(file, fset)
+	translated, err := goParser.ParseFile(fset, filename, out, 0)
+	if err != nil {
+		return fmt.Errorf("translation with line directives is not plain Go: %s", err)
+	}
+	if err := typeCheck(fset, []*ast.File{translated}); err != nil {
+		return fmt.Errorf("translation with line directives does not type-check: %s", err)
+	}
+
+	idents := make(map[string]string)
+	var s scanner.Scanner
+	srcFile := token.NewFileSet().AddFile(filename, -1, len(src))
+	s.Init(srcFile, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT {
+			position := srcFile.Position(pos)
+			idents[fmt.Sprintf("%d:%d", position.Line, position.Column)] = lit
+		}
+	}
+
+	helpers := token.Pos(fset.File(translated.Pos()).Base() + len(out))
+	if i := strings.Index(out, parser.HelpersMarker); i >= 0 {
+		helpers = token.Pos(fset.File(translated.Pos()).Base() + i)
+	}
+
+	var mismatch error
+	ast.Inspect(translated, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			// the parameters of a hoisted call are named after the labels
+			params := n.Type.Params.List
+			return len(params) == 0 || len(params[0].Names) == 0 || !strings.HasPrefix(params[0].Names[0].Name, "_")
+
+		case *ast.CallExpr:
+			fun, ok := n.Fun.(*ast.Ident)
+			return !ok || !strings.Contains(fun.Name, "_default_")
+
+		case *ast.Ident:
+			position := fset.Position(n.Pos())
+			want, ok := idents[fmt.Sprintf("%d:%d", position.Line, position.Column)]
+			if n.Pos() < helpers && ok && want != n.Name && !strings.HasPrefix(n.Name, want+"_") && mismatch == nil {
+				mismatch = fmt.Errorf("line directive maps %s to %s, where the source has %s", n.Name, position, want)
+			}
+		}
+
+		return mismatch == nil
+	})
+
+	return mismatch
+}
+
+// errorComment matches a comment that expects an error on its line.
+var errorComment = regexp.MustCompile(`// ERROR "([^"]*)"`)
+
+// expectedErrors returns the patterns of the ERROR comments of src, by line.
+func expectedErrors(src []byte) map[int]*regexp.Regexp {
+	expected := make(map[int]*regexp.Regexp)
+	for i, line := range strings.Split(string(src), "\n") {
+		if m := errorComment.FindStringSubmatch(line); m != nil {
+			expected[i+1] = regexp.MustCompile(m[1])
+		}
+	}
+
+	return expected
+}
+
+// checkErrors parses src, which must fail with the expected errors. If it
+// parses, the errors are those of the checks of its declarations and calls.
+func checkErrors(filename string, src []byte, expected map[int]*regexp.Regexp) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, goParser.AllErrors)
+	if err == nil {
+		err = parser.CheckCollisions(fset, []*ast.File{file})
+	}
+	if err == nil {
+		err = parser.NewIndex([]*ast.File{file}).Check(fset, file)
+	}
+	if err == nil {
+		return fmt.Errorf("no errors")
+	}
+
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return err
+	}
+
+	for _, e := range list {
+		re, ok := expected[e.Pos.Line]
+		if !ok || !re.MatchString(e.Msg) {
+			return fmt.Errorf("unexpected error\n\t%s", e)
+		}
+		delete(expected, e.Pos.Line)
+	}
+
+	var missing []int
+	for line := range expected {
+		missing = append(missing, line)
+	}
+	if len(missing) > 0 {
+		sort.Ints(missing)
+		return fmt.Errorf("missing error\n\t%s:%d: %s", filename, missing[0], expected[missing[0]])
+	}
+
+	return nil
+}
+
+func check(filename string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if expected := expectedErrors(src); len(expected) > 0 {
+		return checkErrors(filename, src, expected)
+	}
+
+	if err := compareAST(filename, src); err != nil {
+		return err
+	}
+
+	if err := compareRender(filename, src); err != nil {
+		return err
+	}
+
+	return compareLineDirectives(filename, src)
+}
+
+// runTool runs the tool in dir with args, and returns its output. An exit
+// status other than zero is returned as an error, with the output.
+func runTool(tool, dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command(tool, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("go-named-params %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return out, nil
+}
+
+// copyFixture copies the fixture filename into dir.
+func copyFixture(filename, dir string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, filename), src, 0644)
+}
+
+// plainGo returns the translation src formatted by gofmt and without its
+// line directives, which depend on the positions in the named source.
+func plainGo(src []byte) ([]byte, error) {
+	src = regexp.MustCompile(`/\*line [^*]*\*/|(?m)^//line .*\n`).ReplaceAll(src, nil)
+
+	return format.Source(src)
+}
+
+// testUndo checks that the named source restored by undo translates into
+// the same Go as the fixture it was translated from. The named source
+// itself may differ, since undo writes the labels in the order of the
+// declaration, and an argument that is the variable of its label in the
+// short form.
+func testUndo(tool, dir string) error {
+	fixtures, _ := filepath.Glob("*_expected.txt")
+	for _, expected := range fixtures {
+		filename := strings.TrimSuffix(expected, "_expected.txt") + ".go"
+		fixtureDir := filepath.Join(dir, strings.TrimSuffix(filename, ".go"))
+		if err := os.Mkdir(fixtureDir, 0755); err != nil {
+			return err
+		}
+		if err := copyFixture(filename, fixtureDir); err != nil {
+			return err
+		}
+
+		outName := filepath.Join(fixtureDir, strings.TrimSuffix(filename, ".go")+"_gen.go")
+		var outs [2][]byte
+		for i := range outs {
+			if _, err := runTool(tool, fixtureDir, filename); err != nil {
+				return err
+			}
+			out, err := ioutil.ReadFile(outName)
+			if err == nil {
+				outs[i], err = plainGo(out)
+			}
+			if err != nil {
+				return err
+			}
+
+			if _, err := runTool(tool, fixtureDir, "undo", "-w", filepath.Base(outName)); err != nil {
+				return err
+			}
+		}
+
+		if !bytes.Equal(outs[0], outs[1]) {
+			return fmt.Errorf("%s: translation of undo differs from the translation", filename)
+		}
+	}
+
+	return nil
+}
+
+// testUndoSameName checks that undo only restores the function that was
+// declared with named parameters, and its calls, when a method of another
+// type has the same name without them.
+func testUndoSameName(tool, dir string) error {
+	const src = `// Code generated by go-named-params. DO NOT EDIT.
+
+package main
+
+type T struct{}
+
+func add_x_y(x int, y int) int { return x + y }
+
+func (T) add_x_y(int, int) int { return 0 }
+
+func main() { println(add_x_y(1, 2), T{}.add_x_y(1, 2)) }
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "same_gen.go"), []byte(src), 0644); err != nil {
+		return err
+	}
+
+	out, err := runTool(tool, dir, "undo", "same_gen.go")
+	if err != nil {
+		return err
+	}
+	for _, want := range []string{"func add(x: int, y: int) int", "func (T) add_x_y(int, int) int", "println(add(x: 1, y: 2), T{}.add_x_y(1, 2))"} {
+		if !bytes.Contains(out, []byte(want)) {
+			return fmt.Errorf("undo does not restore %q:\n%s", want, out)
+		}
+	}
+
+	return nil
+}
+
+// testEject checks that eject writes packages that are plain Go, formatted
+// by gofmt, without any trace of the named parameters, and that it renames
+// the functions that have no overloads back to their names in the named
+// source. The calls of lib leave out default values and reorder labels of
+// functions declared in another file, which imports what they refer to.
+func testEject(tool, dir string) error {
+	if err := copyFixture("defaults.go", dir); err != nil {
+		return err
+	}
+	for filename, src := range buildModule {
+		if strings.HasPrefix(filename, "lib/") {
+			filename = filepath.Join(dir, filepath.FromSlash(filename))
+			if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	declares := map[string][]string{
+		".":   {"func dial(", "func (p point) move(", "func scale_p_by("},
+		"lib": {"func Greet(", "func Hello("},
+	}
+	for pkg, wants := range declares {
+		out := filepath.Join("out", pkg)
+		if _, err := runTool(tool, dir, "eject", "-o", out, pkg); err != nil {
+			return err
+		}
+
+		filenames, err := filepath.Glob(filepath.Join(dir, out, "*.go"))
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		var files []*ast.File
+		var all []byte
+		for _, filename := range filenames {
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			if formatted, err := format.Source(src); err != nil || !bytes.Equal(formatted, src) {
+				return fmt.Errorf("ejected file %s is not formatted", filename)
+			}
+			for _, marker := range []string{"/*named*/", "/*=", "//named:"} {
+				if bytes.Contains(src, []byte(marker)) {
+					return fmt.Errorf("ejected file %s contains %s:\n%s", filename, marker, src)
+				}
+			}
+
+			file, err := goParser.ParseFile(fset, filename, src, 0)
+			if err != nil {
+				return err
+			}
+			files = append(files, file)
+
+			// the comments of the fixtures talk about named parameters
+			var code bytes.Buffer
+			printer.Fprint(&code, fset, file)
+			for _, trace := range []string{"named", "_default_", "_param_"} {
+				if bytes.Contains(code.Bytes(), []byte(trace)) {
+					return fmt.Errorf("ejected file %s contains %s:\n%s", filename, trace, code.Bytes())
+				}
+			}
+			all = append(all, src...)
+		}
+		if err := typeCheck(fset, files); err != nil {
+			return fmt.Errorf("ejected package %s does not type-check: %s", pkg, err)
+		}
+
+		for _, want := range wants {
+			if !bytes.Contains(all, []byte(want)) {
+				return fmt.Errorf("ejected package %s does not declare %s...)", pkg, want)
+			}
+		}
+	}
+
+	return nil
+}
+
+// testCheck checks that -check and -d accept an output file that is up to
+// date, and report one that is not, without writing it.
+func testCheck(tool, dir string) error {
+	if err := copyFixture("test.go", dir); err != nil {
+		return err
+	}
+	if _, err := runTool(tool, dir, "test.go"); err != nil {
+		return err
+	}
+	for _, flag := range []string{"-check", "-d"} {
+		if out, err := runTool(tool, dir, flag, "test.go"); err != nil || len(out) > 0 {
+			return fmt.Errorf("%s reports an output file that is up to date: %s", flag, out)
+		}
+	}
+
+	outName := filepath.Join(dir, "test_gen.go")
+	out, err := ioutil.ReadFile(outName)
+	if err != nil {
+		return err
+	}
+	stale := append(out, "// stale\n"...)
+	if err := ioutil.WriteFile(outName, stale, 0644); err != nil {
+		return err
+	}
+
+	if out, err := runTool(tool, dir, "-check", "test.go"); err == nil || !bytes.Contains(out, []byte("test_gen.go: out of date")) {
+		return fmt.Errorf("-check does not report a stale output file: %s", out)
+	}
+	if out, err := runTool(tool, dir, "-d", "test.go"); err == nil || !bytes.Contains(out, []byte("\n-// stale\n")) {
+		return fmt.Errorf("-d does not show the diff of a stale output file: %s", out)
+	}
+
+	if current, err := ioutil.ReadFile(outName); err != nil || !bytes.Equal(current, stale) {
+		return fmt.Errorf("-check or -d wrote the output file")
+	}
+
+	return nil
+}
+
+// buildModule is a module whose packages are only named sources. Hello
+// leaves out a default value that refers to an import of another file, and
+// reorders arguments with side effects whose types refer to it. main leaves
+// out default values of lib, which it cannot see.
+var buildModule = map[string]string{
+	"go.mod": "module example\n\ngo 1.21\n",
+	"main.go": `// +build ignore
+
+package main
+
+import (
+	"fmt"
+
+	"example/lib"
+)
+
+func main() {
+	if lib.Greet(name: "gopher") != "hello, gopher" || lib.Mark() != "!" {
+		panic("default values of another package")
+	}
+	fmt.Println(lib.Hello(name: "gopher"))
+}
+`,
+	"lib/greet.go": `// +build ignore
+
+package lib
+
+import "time"
+
+func Greet(name: string, wait: time.Duration = time.Millisecond) string {
+	time.Sleep(wait)
+	return "hello, " + name
+}
+
+func pause() time.Duration {
+	return time.Millisecond
+}
+
+func Mark(punct: string = "!") string {
+	return punct
+}
+`,
+	"lib/hello.go": `// +build ignore
+
+package lib
+
+import "strings"
+
+func Hello(name: string) string {
+	if Greet(wait: pause(), name: strings.ToUpper(name)) != "hello, GOPHER" {
+		panic("reordered arguments")
+	}
+	return Greet(name:)
+}
+`,
+}
+
+// testBuild checks that the go commands run by the tool build the named
+// sources of a package and of the packages it imports, given by directory
+// or by import path, without writing their translations.
+func testBuild(tool, dir string) error {
+	for filename, src := range buildModule {
+		filename = filepath.Join(dir, filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, args := range [][]string{{"run", "."}, {"run", "example"}, {"build", "-o", os.DevNull, "example"}} {
+		cmd := exec.Command(tool, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod", "GOWORK=off")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("go-named-params %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+		if args[0] == "run" && string(out) != "hello, gopher\n" {
+			return fmt.Errorf("go-named-params %s: unexpected output %q", strings.Join(args, " "), out)
+		}
+	}
+
+	for _, pattern := range []string{"*_gen.go", "*/*_gen.go"} {
+		if generated, _ := filepath.Glob(filepath.Join(dir, pattern)); len(generated) > 0 {
+			return fmt.Errorf("translations written: %s", strings.Join(generated, ", "))
+		}
+	}
+
+	return nil
+}
+
+// headerTests are named sources with the header that their translations
+// must start with.
+var headerTests = []struct {
+	src, header string
+}{
+	{
+		"//go:generate go-named-params $GOFILE\n// +build ignore\n\npackage main\n",
+		"//named:go:generate go-named-params $GOFILE\n// Code generated by go-named-params. DO NOT EDIT.\n//line header.go:3\n\npackage main\n",
+	},
+	{
+		"// +build ignore\n\n// Package main says hello.\npackage main\n",
+		"// Code generated by go-named-params. DO NOT EDIT.\n//line header.go:2\n\n// Package main says hello.\npackage main\n",
+	},
+	{
+		"package main\n",
+		"// Code generated by go-named-params. DO NOT EDIT.\n//line header.go:1\npackage main\n",
+	},
+	{
+		"//go:build ignore\n\npackage main\n",
+		"// Code generated by go-named-params. DO NOT EDIT.\n//line header.go:1\n//named:go:build ignore\n\npackage main\n",
+	},
+	{
+		"//go:build ignore\n// +build ignore\n\npackage main\n",
+		"// Code generated by go-named-params. DO NOT EDIT.\n//line header.go:1\n//named:go:build ignore\n//named: +build ignore\n\npackage main\n",
+	},
+}
+
+// testHeader checks that a translation replaces the build constraint of its
+// named source with the generated code marker, followed by a line directive,
+// and keeps the go:generate directive of the tool in a form that go generate
+// does not run. Other forms of the constraint are kept in the same way. Undo
+// must restore the header, with the constraint in the form it had.
+func testHeader(tool, dir string) error {
+	const body = "\nfunc greet(name: string) string { return name }\n\nfunc main() { println(greet(name: \"x\")) }\n"
+	filename := filepath.Join(dir, "header.go")
+	for _, t := range headerTests {
+		if err := ioutil.WriteFile(filename, []byte(t.src+body), 0644); err != nil {
+			return err
+		}
+		if _, err := runTool(tool, dir, "header.go"); err != nil {
+			return err
+		}
+
+		out, err := ioutil.ReadFile(filepath.Join(dir, "header_gen.go"))
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(out, []byte(t.header)) {
+			return fmt.Errorf("translation of %q starts with %q, want %q", t.src, out[:len(t.header)], t.header)
+		}
+
+		restored, err := runTool(tool, dir, "undo", "header_gen.go")
+		if err != nil {
+			return err
+		}
+		want := t.src
+		if !strings.Contains(want, "build ignore") {
+			want = "// +build ignore\n\n" + want
+		}
+		if !bytes.HasPrefix(restored, []byte(want)) {
+			return fmt.Errorf("undo of %q restores the header %q", t.src, restored)
+		}
+	}
+
+	return nil
+}
+
+// testSiblingCollisions checks that the mangled names of a named source
+// are checked against the declarations of the other named sources of its
+// package, which are built as their translations.
+func testSiblingCollisions(tool, dir string) error {
+	files := map[string]string{
+		"open.go": "// +build ignore\n\npackage main\n\nfunc open(path: string) {}\n\nfunc main() { open(path: \"/\") }\n",
+		"vars.go": "// +build ignore\n\npackage main\n\nvar open_path = \"/\"\n\nfunc close(path: string) {}\n",
+	}
+	for filename, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, filename), []byte(src), 0644); err != nil {
+			return err
+		}
+	}
+
+	out, err := runTool(tool, dir, "open.go")
+	if err == nil || !bytes.Contains(out, []byte("mangled name open_path of open(path) collides with var open_path at vars.go:5:5")) {
+		return fmt.Errorf("collision with a sibling named source not reported: %s", out)
+	}
+
+	return nil
+}
+
+// treeFiles is a tree of packages for testTree. The named sources in the
+// directories that the go command ignores must not be translated.
+var treeFiles = map[string]string{
+	"a.go":              "// +build ignore\n\npackage a\n\nfunc f(x: int) int { return f(x: x) }\n",
+	"plain.go":          "package a\n\nfunc g() {}\n",
+	"sub/b.go":          "// +build ignore\n\npackage b\n\nfunc f(x: int) int { return f(x: x) }\n",
+	"broken/c.go":       "// +build ignore\n\npackage c\n\nfunc f(x: int {\n",
+	"testdata/t.go":     "// +build ignore\n\npackage t\n\nfunc f(x: int) {}\n",
+	"_hidden/h.go":      "// +build ignore\n\npackage h\n\nfunc f(x: int) {}\n",
+	".dot/d.go":         "// +build ignore\n\npackage d\n\nfunc f(x: int) {}\n",
+	"sub/testdata/t.go": "// +build ignore\n\npackage t\n\nfunc f(x: int) {}\n",
+}
+
+// testTree checks that a directory argument translates the named sources
+// of that directory only, and that dir/... translates the whole tree except
+// for the directories that the go command ignores. Plain files are skipped
+// and a file that does not parse fails, which the summary counts and the
+// exit status reports.
+func testTree(tool, dir string) error {
+	for filename, src := range treeFiles {
+		filename = filepath.Join(dir, "tree", filepath.FromSlash(filename))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			return err
+		}
+	}
+
+	tests := []struct {
+		arg, summary string
+		status       int
+		generated    []string
+	}{
+		{"tree", "1 translated, 1 skipped, 0 failed", 0, []string{"a_gen.go"}},
+		// the translation of a.go is plain Go now
+		{"tree/...", "2 translated, 2 skipped, 1 failed", 1, []string{"a_gen.go", "sub/b_gen.go"}},
+	}
+	for _, t := range tests {
+		cmd := exec.Command(tool, t.arg)
+		cmd.Dir = dir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+
+		status := 0
+		if exit, ok := err.(*exec.ExitError); ok {
+			status = exit.ExitCode()
+		} else if err != nil {
+			return err
+		}
+		if status != t.status {
+			return fmt.Errorf("go-named-params %s: exit status %d, want %d\n%s", t.arg, status, t.status, stderr.Bytes())
+		}
+		if summary := strings.TrimSpace(stdout.String()); summary != t.summary {
+			return fmt.Errorf("go-named-params %s: summary %q, want %q", t.arg, summary, t.summary)
+		}
+		if t.status != 0 && !bytes.Contains(stderr.Bytes(), []byte("c.go:5:")) {
+			return fmt.Errorf("go-named-params %s: the error of broken/c.go is not reported:\n%s", t.arg, stderr.Bytes())
+		}
+
+		var generated []string
+		filepath.Walk(filepath.Join(dir, "tree"), func(path string, info os.FileInfo, err error) error {
+			if err == nil && strings.HasSuffix(path, "_gen.go") {
+				rel, _ := filepath.Rel(filepath.Join(dir, "tree"), path)
+				generated = append(generated, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if strings.Join(generated, " ") != strings.Join(t.generated, " ") {
+			return fmt.Errorf("go-named-params %s: generated %v, want %v", t.arg, generated, t.generated)
+		}
+	}
+
+	return nil
+}
+
+// toolTests are the tests of the commands of the tool.
+var toolTests = []struct {
+	name string
+	test func(tool, dir string) error
+}{
+	{"undo", testUndo},
+	{"unmangle", testUndoSameName},
+	{"eject", testEject},
+	{"check", testCheck},
+	{"build", testBuild},
+	{"header", testHeader},
+	{"collisions", testSiblingCollisions},
+	{"tree", testTree},
+}
+
+// runToolTests builds the tool and runs toolTests. It reports whether they
+// passed.
+func runToolTests() bool {
+	dir, err := ioutil.TempDir("", "go-named-params-test")
+	if err != nil {
+		fmt.Printf("FAIL tool: %s\n", err)
+		return false
+	}
+	defer os.RemoveAll(dir)
+
+	tool := filepath.Join(dir, "go-named-params")
+	if out, err := exec.Command("go", "build", "-o", tool, "..").CombinedOutput(); err != nil {
+		fmt.Printf("FAIL tool: go build: %s\n%s", err, out)
+		return false
+	}
+
+	passed := true
+	for _, t := range toolTests {
+		testDir := filepath.Join(dir, t.name)
+		err := os.Mkdir(testDir, 0755)
+		if err == nil {
+			err = t.test(tool, testDir)
+		}
+
+		if err != nil {
+			fmt.Printf("FAIL tool %s: %s\n", t.name, err)
+			passed = false
+		} else {
+			fmt.Printf("ok   tool %s\n", t.name)
+		}
+	}
+
+	return passed
+}
+
+func main() {
+	fixtures := os.Args[1:]
+	if len(fixtures) == 0 {
+		fixtures, _ = filepath.Glob("*.go")
+	}
+
+	failed := false
+	for _, filename := range fixtures {
+		if filename == "run.go" || strings.HasSuffix(filename, "_gen.go") {
+			continue
+		}
+
+		if err := check(filename); err != nil {
+			fmt.Printf("FAIL %s: %s\n", filename, err)
+			failed = true
+		} else {
+			fmt.Printf("ok   %s\n", filename)
+		}
+	}
+
+	if len(os.Args) == 1 && !runToolTests() {
+		failed = true
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
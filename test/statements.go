@@ -0,0 +1,122 @@
+// +build ignore
+
+package main
+
+// Statements and declarations
+// ===========================
+//
+// Every kind of node must be rendered, whether or not the file uses named
+// parameters. This file is plain Go, so its translation must be the same
+// program.
+
+import (
+	"fmt"
+	str "strings"
+)
+
+import "os"
+
+const (
+	zero = iota
+	one
+	two
+)
+
+const greeting, answer = "hello", 42
+
+type (
+	celsius float64
+	shape   interface {
+		Area() float64
+		fmt.Stringer
+	}
+	handler func(string, ...int) (int, error)
+	pair    struct {
+		key, value string `kv:"pair"`
+		*os.File
+	}
+)
+
+var usage = `usage:
+	statements [-v]
+`
+
+var (
+	in  <-chan int
+	out chan<- int
+	both chan (<-chan int)
+)
+
+func closures() func() int {
+	n := 0
+	inc := func() int { n++; return n }
+	defer func() { n = 0 }()
+	go inc()
+	return func() int {
+		return inc() + 1
+	}
+}
+
+func branches(x int) (s string, err error) {
+	if x < 0 {
+		return "", fmt.Errorf("negative: %d", x)
+	} else if x == 0 {
+		s = "zero"
+	} else {
+		s = fmt.Sprint(x)
+	}
+
+	if n := len(s); n > 1 {
+		s = str.Repeat(s, n)
+	}
+
+	for {
+		break
+	}
+	for x > 10 {
+		x /= 2
+	}
+	for i := 0; i < x; i++ {
+		x -= i
+	}
+	for i := 0; i < 3; {
+		i++
+	}
+	for range s {
+	}
+	for i := range s {
+		_ = i
+	}
+	for _, r := range s {
+		_ = r
+	}
+
+	a, b := 1, 2; a, b = b, a
+	a += b; b <<= 1; a &^= b
+
+	return
+}
+
+func channels(ch chan int, done chan struct{}) {
+	ch <- 1
+	v, ok := <-ch
+	_, _ = v, ok
+	close(done)
+	var p *int = new(int)
+	*p = -*p
+	_ = &p
+	_ = !ok
+	_ = ^v
+	_ = []interface{}{ch, (<-chan int)(ch)}
+}
+
+func (c celsius) String() string {
+	return fmt.Sprintf("%.1f°C", float64(c))
+}
+
+func (p *pair) swap() (key, value string) {
+	p.key, p.value = p.value, p.key
+	return p.key, p.value
+}
+
+func (pair) empty() bool { return false }
@@ -74,6 +74,26 @@ func named15(a,
   return a + b * len(c)
 }
 
+// named16 has a doc comment, which stays attached to named16_a_b.
+func named16(a: int, /* the second */ b: int) int { // trailing comment
+  return a - b
+}
+
+// Receivers and results
+type counter struct {
+  n int
+}
+
+func (c *counter) add(by: int) (int, error) {
+  c.n += by
+  return c.n, nil
+}
+
+func (c counter) times(a: int, b: int) (result int) {
+  result = c.n * a * b
+  return
+}
+
 // Helper functions
 func check(result, expectedResult int) {
   if result != expectedResult {
@@ -128,6 +148,18 @@ func main() {
   result = ((((((((1 + 3))))))))
   check(result, 4)
 
+  // Comments between arguments.
+  result = named16(a: 5, // five
+    b: /* three */ 3)
+  check(result, 2)
+
+  // Methods keep their receivers and results.
+  c := &counter{}
+  result, _ = c.add(by: 2)
+  check(result, 2)
+  result = c.times(a: 3, b: 4)
+  check(result, 24)
+
   // Combinations of new lines.
   anon10(
   )
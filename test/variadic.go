@@ -0,0 +1,52 @@
+// +build ignore
+
+package main
+
+// Variadic parameters
+// ===================
+//
+// The label of a variadic parameter is written once, before all of its
+// values, so it must be the last named argument.
+
+func join(sep: string, parts: ...string) string {
+	s := ""
+	for i, part := range parts {
+		if i > 0 {
+			s += sep
+		}
+		s += part
+	}
+	return s
+}
+
+func sum(numbers: ...int) (total int) {
+	for _, n := range numbers {
+		total += n
+	}
+	return
+}
+
+func check(result, expectedResult string) {
+	if result != expectedResult {
+		panic("Failed!")
+	}
+}
+
+func main() {
+	check(join(sep: ",", parts: "a"), "a")
+	check(join(sep: ",", parts: "a", "b", "c"), "a,b,c")
+	check(join(sep: "-",
+		parts: "a",
+		"b",
+	), "a-b")
+
+	xs := []string{"x", "y"}
+	check(join(sep: "+", parts: xs...), "x+y")
+	check(join(sep: "", parts: nil...), "")
+
+	check(join(sep: " ", parts: join(sep: "", parts: "1", "2"), "3"), "12 3")
+
+	if sum(numbers: 1, 2, 3) != 6 || sum(numbers: []int{4, 5}...) != 9 {
+		panic("Failed!")
+	}
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	goParser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"./parser"
+)
+
+// A mangledFunc describes a function declaration whose name was produced by
+// translating a declaration with named parameters.
+type mangledFunc struct {
+	base     string   // name before translation
+	labels   []string // parameter labels, in declaration order
+	variadic bool     // the last parameter is variadic
+}
+
+// unmangle reports whether the function or method name of type typ looks
+// like the translation of a declaration with named parameters, that is, its
+// name is a base name followed by the label of every parameter, each
+// preceded by an underscore.
+func unmangle(ident *ast.Ident, typ *ast.FuncType, comments []*ast.CommentGroup) (mangledFunc, bool) {
+	fn, ok := translatedParams(typ, comments)
+	suffix := "_" + strings.Join(fn.labels, "_")
+	name := ident.Name
+	if !ok || len(name) <= len(suffix) || !strings.HasSuffix(name, suffix) {
+		return mangledFunc{}, false
+	}
+
+	fn.base = strings.TrimSuffix(name, suffix)
+
+	return fn, true
+}
+
+// translatedParams returns the parameters of typ, the translation of a func
+// type with named parameters, without a base name. The label of a parameter
+// is its name, unless one of comments holds another label for it. It
+// reports false if a parameter has no name.
+func translatedParams(typ *ast.FuncType, comments []*ast.CommentGroup) (mangledFunc, bool) {
+	var labels []string
+	for i, field := range typ.Params.List {
+		if len(field.Names) == 0 {
+			return mangledFunc{}, false
+		}
+
+		if label := labelComment(comments, typ.Params, i); label != nil {
+			labels = append(labels, label.Text[len("/*"):len(label.Text)-len("*/")])
+			continue
+		}
+		for _, name := range field.Names {
+			labels = append(labels, name.Name)
+		}
+	}
+	if len(labels) == 0 {
+		return mangledFunc{}, false
+	}
+
+	last := typ.Params.List[len(typ.Params.List)-1]
+	_, variadic := last.Type.(*ast.Ellipsis)
+
+	return mangledFunc{labels: labels, variadic: variadic}, true
+}
+
+// namedFuncTypeMarker returns the comment of comments that marks typ as the
+// translation of a func type with named parameters, or nil if there is none.
+func namedFuncTypeMarker(comments []*ast.CommentGroup, typ *ast.FuncType) *ast.Comment {
+	if !typ.Func.IsValid() {
+		return nil
+	}
+
+	for _, group := range comments {
+		for _, comment := range group.List {
+			if comment.Text == parser.NamedFuncTypeMarker && comment.Pos() > typ.Func && comment.End() <= typ.Params.Opening {
+				return comment
+			}
+		}
+	}
+
+	return nil
+}
+
+// labelComment returns the comment that holds the label of the i-th field of
+// params, such as "/*from*/" in "func move_from_to(/*from*/ src string)", or
+// nil if there is none. The labels of the other parameters are their names.
+func labelComment(comments []*ast.CommentGroup, params *ast.FieldList, i int) *ast.Comment {
+	field := params.List[i]
+	after := params.Opening
+	if i > 0 {
+		after = params.List[i-1].End()
+	}
+
+	for _, group := range comments {
+		for _, comment := range group.List {
+			text := comment.Text
+			if comment.Pos() > after && comment.End() <= field.Pos() && len(field.Names) == 1 &&
+				strings.HasPrefix(text, "/*") && token.IsIdentifier(strings.TrimSuffix(text[len("/*"):], "*/")) {
+				return comment
+			}
+		}
+	}
+
+	return nil
+}
+
+// interfaceMethods calls fn for each method declared by the interface
+// type typ. Embedded interfaces are skipped.
+func interfaceMethods(typ *ast.InterfaceType, fn func(name *ast.Ident, typ *ast.FuncType)) {
+	for _, field := range typ.Methods.List {
+		if method, ok := field.Type.(*ast.FuncType); ok && len(field.Names) == 1 {
+			fn(field.Names[0], method)
+		}
+	}
+}
+
+// mangledFuncs returns the mangled declarations of files, by name. These
+// are the functions and methods, and the methods of interface types.
+func mangledFuncs(files []*ast.File) map[string]mangledFunc {
+	funcs := make(map[string]mangledFunc)
+	var comments []*ast.CommentGroup
+	add := func(name *ast.Ident, typ *ast.FuncType) {
+		if fn, ok := unmangle(name, typ, comments); ok {
+			funcs[name.Name] = fn
+		}
+	}
+
+	for _, file := range files {
+		comments = file.Comments
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				add(n.Name, n.Type)
+			case *ast.InterfaceType:
+				interfaceMethods(n, add)
+			}
+
+			return true
+		})
+	}
+
+	return funcs
+}
+
+// defaultFuncs returns the names of the functions that return the default
+// values of named parameters in files. The translation declares them after
+// parser.HelpersMarker.
+func defaultFuncs(files []*ast.File) map[string]bool {
+	funcs := make(map[string]bool)
+	for _, file := range files {
+		marker := helpersMarker(file)
+		if marker == nil {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Pos() > marker.Pos() {
+				funcs[decl.Name.Name] = true
+			}
+		}
+	}
+
+	return funcs
+}
+
+// helpersMarker returns the comment of file that precedes the helpers of
+// the translation, such as the functions returning default values, or nil
+// if there is none.
+func helpersMarker(file *ast.File) *ast.Comment {
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if comment.Text == parser.HelpersMarker {
+				return comment
+			}
+		}
+	}
+
+	return nil
+}
+
+// funcName returns the identifier that names the function fun, or nil if
+// it is not referred to by name.
+func funcName(fun ast.Expr) *ast.Ident {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	}
+
+	return nil
+}
+
+// An edit replaces the bytes from start to end with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits returns src with edits applied. Edits must not overlap, but an
+// insertion may be at the start of a replacement.
+func applyEdits(src []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start > edits[j].start
+		}
+
+		return edits[i].end > edits[j].end
+	})
+
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+
+	return out
+}
+
+// undoer rewrites the translation of a named source back into named
+// parameter syntax.
+type undoer struct {
+	fset     *token.FileSet
+	funcs    map[string]mangledFunc
+	defaults map[string]bool        // the functions returning default values
+	siblings map[string][]*ast.File // the files of each directory
+	index    *parser.Index          // of the package of file
+	file     *ast.File
+	src      []byte
+	comments []*ast.CommentGroup // of the package of file
+	edits    []edit
+}
+
+func (u *undoer) offset(pos token.Pos) int {
+	return u.fset.Position(pos).Offset
+}
+
+func (u *undoer) replace(start, end token.Pos, text string) {
+	u.edits = append(u.edits, edit{u.offset(start), u.offset(end), text})
+}
+
+// remove removes the bytes from start to end, with the edits of the line
+// directives between them.
+func (u *undoer) remove(start, end int) {
+	edits := u.edits[:0]
+	for _, e := range u.edits {
+		if e.start < start || e.end > end {
+			edits = append(edits, e)
+		}
+	}
+
+	u.edits = append(edits, edit{start, end, ""})
+}
+
+// label inserts "label: " before arg. The spaces that were left in place
+// of the label by the translation are replaced. An argument that is the
+// identifier label is punned, that is, replaced by "label:".
+func (u *undoer) label(arg ast.Expr, label string) {
+	text := label + ": "
+	end := u.offset(arg.Pos())
+	if ident, ok := arg.(*ast.Ident); ok && ident.Name == label {
+		text = label + ":"
+		end = u.offset(arg.End())
+		if end < len(u.src) && u.src[end] == ' ' {
+			// the space left in place of the colon
+			end++
+		}
+	}
+
+	start := u.offset(arg.Pos())
+	for start > 0 && u.src[start-1] == ' ' {
+		start--
+	}
+
+	switch {
+	case start == 0 || u.src[start-1] == '\n' || u.src[start-1] == '\t':
+		// keep the indentation of arguments on their own line
+	case u.src[start-1] != '(':
+		text = " " + text
+	}
+
+	u.edits = append(u.edits, edit{start, end, text})
+}
+
+// signature restores the named parameters of the function or method name
+// of type typ. Another function or method may have the same name, so typ
+// decides whether name is mangled.
+func (u *undoer) signature(name *ast.Ident, typ *ast.FuncType) {
+	fn, ok := unmangle(name, typ, u.comments)
+	if !ok {
+		return
+	}
+
+	u.replace(name.Pos(), name.End(), fn.base)
+	u.params(typ)
+}
+
+// funcType restores the named parameters of the func type or function
+// literal typ, if the translation marked it as having them.
+func (u *undoer) funcType(typ *ast.FuncType) {
+	marker := namedFuncTypeMarker(u.comments, typ)
+	if marker == nil {
+		return
+	}
+
+	// The line directive that follows the marker is separated from it by
+	// a space.
+	start, end := u.offset(marker.Pos()), u.offset(marker.End())
+	if bytes.HasPrefix(u.src[end:], []byte(" /*line ")) {
+		end++
+	}
+	u.edits = append(u.edits, edit{start, end, ""})
+	u.params(typ)
+}
+
+// params restores the labels, colons and default values of the parameters
+// of typ.
+func (u *undoer) params(typ *ast.FuncType) {
+	for i, field := range typ.Params.List {
+		if label := labelComment(u.comments, typ.Params, i); label != nil {
+			start, end := u.offset(label.Pos()), u.offset(label.End())
+			if u.src[end] == ' ' {
+				end++
+			}
+			u.edits = append(u.edits, edit{start, end, label.Text[len("/*"):len(label.Text)-len("*/")] + " "})
+		}
+		last := field.Names[len(field.Names)-1]
+		u.replace(last.End(), field.Type.Pos(), ": ")
+
+		end := typ.Params.Closing
+		if i+1 < len(typ.Params.List) {
+			end = typ.Params.List[i+1].Pos()
+		}
+		if def := u.defaultValue(field.Type.End(), end); def != nil {
+			u.defaultValueText(def)
+		}
+	}
+}
+
+// defaultValue returns the comment between start and end that holds the
+// default value of a parameter, such as "/*= 443*/", or nil if there is
+// none.
+func (u *undoer) defaultValue(start, end token.Pos) *ast.Comment {
+	for _, group := range u.comments {
+		for _, comment := range group.List {
+			if comment.Pos() >= start && comment.End() <= end && strings.HasPrefix(comment.Text, "/*= ") {
+				return comment
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultValueText replaces the comment def with the default value it
+// holds. The comment is longer than the value, so the line directive that
+// follows it took the place of a space, which is restored.
+func (u *undoer) defaultValueText(def *ast.Comment) {
+	start, end := u.offset(def.Pos()), u.offset(def.End())
+	text := parser.UnescapeComment(strings.TrimSuffix(strings.TrimPrefix(def.Text, "/*"), "*/"))
+	switch directive := []byte("/*line "); {
+	case bytes.HasPrefix(u.src[end:], append([]byte(" "), directive...)):
+		end++
+	case bytes.HasPrefix(u.src[end:], append([]byte(","), directive...)):
+		text += ", "
+		end++
+	}
+
+	u.edits = append(u.edits, edit{start, end, text})
+}
+
+// isDefault reports whether arg is the call of the function that returns
+// the default value of the parameter labelled label, which the translation
+// passes in place of a named argument that was left out.
+func (u *undoer) isDefault(arg ast.Expr, label string) bool {
+	call, ok := arg.(*ast.CallExpr)
+	if !ok || len(call.Args) > 0 {
+		return false
+	}
+	name, ok := call.Fun.(*ast.Ident)
+
+	return ok && u.defaults[name.Name] && strings.HasSuffix(name.Name, "_default_"+label)
+}
+
+// valueFunc returns the parameters of the func type of the func value fun,
+// if the translation marked it as having named parameters.
+func (u *undoer) valueFunc(fun ast.Expr) (mangledFunc, bool) {
+	typ := u.index.FuncValueType(u.file, fun)
+	if typ == nil || namedFuncTypeMarker(u.comments, typ) == nil {
+		return mangledFunc{}, false
+	}
+
+	return translatedParams(typ, u.comments)
+}
+
+// callee returns the mangled function or method that call calls by name.
+// The declaration that the package shows for the call decides, so that a
+// call of another function or method with the same name is left alone.
+// Only if it is not known, as for the methods of interfaces, is the
+// declaration looked up by name.
+func (u *undoer) callee(call *ast.CallExpr, name *ast.Ident) (mangledFunc, bool) {
+	if typ := u.index.CalleeType(u.file, call); typ != nil {
+		return unmangle(name, typ, u.comments)
+	}
+
+	fn, ok := u.funcs[name.Name]
+
+	return fn, ok
+}
+
+// callExpr restores the labels of call, if it calls a mangled function or
+// a func value with named parameters.
+func (u *undoer) callExpr(call *ast.CallExpr) {
+	name := funcName(call.Fun)
+	fn, ok := mangledFunc{}, false
+	if name != nil {
+		fn, ok = u.callee(call, name)
+	}
+	if !ok {
+		fn, ok = u.valueFunc(call.Fun)
+	}
+	if !ok || len(call.Args) < len(fn.labels) ||
+		len(call.Args) > len(fn.labels) && (!fn.variadic || call.Ellipsis.IsValid()) {
+		return
+	}
+
+	if fn.base != "" {
+		u.replace(name.Pos(), name.End(), fn.base)
+	}
+	// the values of a variadic parameter after the first have no label
+	kept := 0
+	for i, label := range fn.labels {
+		if !u.isDefault(call.Args[i], label) {
+			u.label(call.Args[i], label)
+			if kept == 0 && i > 0 {
+				// the label follows the parenthesis
+				u.edits[len(u.edits)-1].text = strings.TrimPrefix(u.edits[len(u.edits)-1].text, " ")
+			}
+			kept++
+			continue
+		}
+
+		switch {
+		case kept > 0:
+			// with the comma before it
+			u.remove(u.offset(call.Args[i-1].End()), u.offset(call.Args[i].End()))
+		case i+1 < len(call.Args) && (i+1 == len(fn.labels) || !u.isDefault(call.Args[i+1], fn.labels[i+1])):
+			// The defaults before the first argument are left out with
+			// the comma after them, and the spaces before the argument
+			// are left to its label.
+			start, end := u.offset(call.Args[0].Pos()), u.offset(call.Args[i+1].Pos())
+			for end > start && u.src[end-1] == ' ' {
+				end--
+			}
+			u.remove(start, end)
+		case i+1 == len(call.Args):
+			u.remove(u.offset(call.Args[0].Pos()), u.offset(call.Args[i].End()))
+		}
+	}
+	if kept == 0 {
+		return
+	}
+
+	// Arguments that were reordered leave the spaces of their labels
+	// before the closing parenthesis.
+	last := call.Args[len(call.Args)-1]
+	start := u.offset(last.End())
+	if call.Ellipsis.IsValid() {
+		start = u.offset(call.Ellipsis) + len("...")
+	} else if ident, ok := last.(*ast.Ident); ok && len(call.Args) == len(fn.labels) && ident.Name == fn.labels[len(fn.labels)-1] {
+		// punned by label
+		start++
+	}
+	end := u.offset(call.Rparen)
+	if start < end && strings.TrimLeft(string(u.src[start:end]), " ") == "" {
+		u.edits = append(u.edits, edit{start, end, ""})
+	}
+}
+
+// hoisted restores a call whose arguments the translation passed through a
+// function literal, to keep the order of their side effects when they were
+// reordered:
+//
+//	func(_b int, _a int) int { return f_a_b(_a, _b) }(g(), h())
+//	func(_0 func(a int, b int), _b int, _a int) { _0(_a, _b) }(x().f_a_b, g(), h())
+//
+// It reports whether call is such a call. The names of the parameters of
+// the function literal are the labels, preceded by an underscore, except
+// for the function, which is _0.
+func (u *undoer) hoisted(call *ast.CallExpr) bool {
+	lit, ok := call.Fun.(*ast.FuncLit)
+	if !ok || len(lit.Body.List) != 1 {
+		return false
+	}
+
+	var inner ast.Expr
+	switch stmt := lit.Body.List[0].(type) {
+	case *ast.ExprStmt:
+		inner = stmt.X
+	case *ast.ReturnStmt:
+		if len(stmt.Results) == 1 {
+			inner = stmt.Results[0]
+		}
+	}
+	innerCall, ok := inner.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	var labels []string
+	for _, field := range lit.Type.Params.List {
+		for _, name := range field.Names {
+			if !strings.HasPrefix(name.Name, "_") {
+				return false
+			}
+			labels = append(labels, strings.TrimPrefix(name.Name, "_"))
+		}
+	}
+
+	args := call.Args
+	if fun, ok := innerCall.Fun.(*ast.Ident); ok && fun.Name == "_0" {
+		// the function is the first argument
+		if len(args) < len(labels) {
+			return false
+		}
+		if name := funcName(args[0]); name != nil {
+			if fn, ok := u.funcs[name.Name]; ok {
+				u.replace(name.Pos(), name.End(), fn.base)
+			}
+		}
+		comma := u.offset(args[0].End()) + bytes.IndexByte(u.src[u.offset(args[0].End()):], ',')
+		u.replace(lit.Pos(), lit.End(), "")
+		u.replace(call.Lparen, call.Lparen+1, "")
+		u.edits = append(u.edits, edit{comma, comma + 1, "("})
+		labels, args = labels[1:], args[1:]
+		if len(args) > 0 {
+			// the first label follows the parenthesis
+			u.label(args[0], labels[0])
+			u.edits[len(u.edits)-1].text = strings.TrimPrefix(u.edits[len(u.edits)-1].text, " ")
+			labels, args = labels[1:], args[1:]
+		}
+	} else {
+		name := funcName(innerCall.Fun)
+		if name == nil || len(args) < len(labels) {
+			return false
+		}
+		base := name.Name
+		if fn, ok := u.funcs[name.Name]; ok {
+			base = fn.base
+		}
+		prefix := u.src[u.offset(innerCall.Fun.Pos()):u.offset(name.Pos())]
+		u.replace(lit.Pos(), lit.End(), string(prefix)+base)
+	}
+
+	for i, label := range labels {
+		u.label(args[i], label)
+	}
+
+	return true
+}
+
+// visit rewrites node, and reports whether its children are to be visited.
+func (u *undoer) visit(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		u.signature(n.Name, n.Type)
+	case *ast.InterfaceType:
+		interfaceMethods(n, u.signature)
+	case *ast.FuncType:
+		u.funcType(n)
+	case *ast.CallExpr:
+		if u.hoisted(n) {
+			// the function literal is replaced as a whole
+			for _, arg := range n.Args {
+				ast.Inspect(arg, u.visit)
+			}
+			return false
+		}
+		u.callExpr(n)
+	}
+
+	return true
+}
+
+// header replaces generatedMarker with the build constraint that keeps the
+// named source out of builds. The constraint must be followed by an empty
+// line, which the marker might not be. If the translation kept the
+// constraints of the named source, the marker was added as a line of its
+// own, and is removed instead. The kept constraints and go:generate
+// directives are restored by undo, with the other comments.
+func (u *undoer) header(marker *ast.Comment, kept bool) {
+	if kept {
+		u.replace(marker.Pos(), marker.End()+1, "")
+		return
+	}
+
+	text := oldIgnoreConstraint
+	next := u.offset(marker.End()) + 1
+	if bytes.HasPrefix(u.src[next:], []byte("//line ")) {
+		next += bytes.IndexByte(u.src[next:], '\n') + 1
+	}
+	if next < len(u.src) && u.src[next] != '\n' {
+		text += "\n"
+	}
+
+	u.replace(marker.Pos(), marker.End(), text)
+}
+
+// setPackage sets the package of file, which is made of file and of the
+// other files of its directory in the same package. Their declarations show
+// the func types of the func values in file.
+func (u *undoer) setPackage(file *ast.File) {
+	filename := u.fset.Position(file.Package).Filename
+	files := []*ast.File{file}
+	for _, sibling := range u.siblings[filepath.Dir(filename)] {
+		if sibling.Name.Name == file.Name.Name && u.fset.Position(sibling.Package).Filename != filename {
+			files = append(files, sibling)
+		}
+	}
+
+	u.file = file
+	u.index = parser.NewIndex(files)
+	u.comments = nil
+	for _, f := range files {
+		u.comments = append(u.comments, f.Comments...)
+	}
+}
+
+// undo returns the named source for file, whose source is src. The line
+// directives and the header added by the translation are removed.
+func (u *undoer) undo(file *ast.File, src []byte) []byte {
+	u.src = src
+	u.edits = nil
+	var marker *ast.Comment
+	kept := false
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			directive := "//" + strings.TrimPrefix(comment.Text, keptDirectivePrefix)
+			switch {
+			case comment.Text == generatedMarker:
+				marker = comment
+			case strings.HasPrefix(comment.Text, keptDirectivePrefix) && comment.Pos() < file.Package &&
+				(isOwnGenerate(directive) || isIgnoreConstraint(directive)):
+				u.replace(comment.Pos(), comment.End(), directive)
+				kept = kept || isIgnoreConstraint(directive)
+			case strings.HasPrefix(comment.Text, "/*line "):
+				u.replace(comment.Pos(), comment.End(), "")
+			case strings.HasPrefix(comment.Text, "//line "):
+				// the directive is on a line of its own
+				u.replace(comment.Pos(), comment.End()+1, "")
+			}
+		}
+	}
+
+	if marker != nil {
+		u.header(marker, kept)
+	}
+
+	ast.Inspect(file, u.visit)
+
+	if marker := helpersMarker(file); marker != nil {
+		// The helpers end the file. The default values they return are
+		// kept in the comments of the parameters.
+		start := u.offset(marker.Pos())
+		for start > 0 && u.src[start-1] == '\n' {
+			start--
+		}
+		u.remove(start, len(src))
+		u.edits = append(u.edits, edit{start, start, "\n"})
+	}
+
+	return applyEdits(src, u.edits)
+}
+
+// sourceFilename is the inverse of outputFilename.
+func sourceFilename(filename string) (string, error) {
+	switch {
+	case strings.HasSuffix(filename, "_gen_test.go"):
+		return strings.TrimSuffix(filename, "_gen_test.go") + "_test.go", nil
+	case strings.HasSuffix(filename, "_gen.go"):
+		return strings.TrimSuffix(filename, "_gen.go") + ".go", nil
+	}
+
+	return "", fmt.Errorf("%s: not a generated file", filename)
+}
+
+// parsePlainFiles parses filenames with the standard parser. Files that are
+// not plain Go are ignored.
+func parsePlainFiles(fset *token.FileSet, filenames []string) (files []*ast.File) {
+	for _, filename := range filenames {
+		if file, err := goParser.ParseFile(fset, filename, nil, goParser.ParseComments); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	return
+}
+
+// runUndo implements the undo command. The declarations of mangled
+// functions are found in the files given and in the other files of their
+// directories, so that calls to functions declared elsewhere in the package
+// are also recognised.
+func runUndo(args []string) int {
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the named source instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: go-named-params undo [-w] file_gen.go ...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var siblings []string
+	dirs := make(map[string]bool)
+	for _, filename := range flags.Args() {
+		dir := filepath.Dir(filename)
+		if !dirs[dir] {
+			dirs[dir] = true
+			matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+			siblings = append(siblings, matches...)
+		}
+	}
+
+	u := &undoer{fset: token.NewFileSet(), siblings: make(map[string][]*ast.File)}
+	files := parsePlainFiles(u.fset, siblings)
+	for _, file := range files {
+		dir := filepath.Dir(u.fset.Position(file.Package).Filename)
+		u.siblings[dir] = append(u.siblings[dir], file)
+	}
+	u.funcs = mangledFuncs(files)
+	u.defaults = defaultFuncs(files)
+
+	exitCode := 0
+	for _, filename := range flags.Args() {
+		if err := u.undoFile(filename, *write); err != nil {
+			report(err)
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+func (u *undoer) undoFile(filename string, write bool) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	file, err := goParser.ParseFile(u.fset, filename, src, goParser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	u.setPackage(file)
+	out := u.undo(file, src)
+	if !write {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+
+	source, err := sourceFilename(filename)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(source, out, info.Mode().Perm())
+}